@@ -13,32 +13,74 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
+	"github.com/sand/crypto-trading-app/backend/internal/audit"
+	"github.com/sand/crypto-trading-app/backend/internal/config"
 	"github.com/sand/crypto-trading-app/backend/internal/handlers"
+	"github.com/sand/crypto-trading-app/backend/internal/logstream"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
 	"github.com/sand/crypto-trading-app/backend/internal/services"
 	"github.com/sand/crypto-trading-app/backend/internal/websocket"
 )
 
 // Server timeout constants.
 const (
-	readTimeoutSeconds     = 15
-	writeTimeoutSeconds    = 15
-	idleTimeoutSeconds     = 60
-	shutdownTimeoutSeconds = 5
+	readTimeoutSeconds  = 15
+	writeTimeoutSeconds = 15
+	idleTimeoutSeconds  = 60
 )
 
 func main() {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logHub := logstream.NewHub()
+	logger := slog.New(logstream.NewHandler(slog.NewTextHandler(os.Stdout, nil), logHub))
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	if err = cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	if cfg.JWT.DevMode {
+		logger.Warn("JWT dev mode accepts unsigned tokens, never enable it in production")
+	}
+
 	// Create services and components
-	dataService := services.NewDataService(logger)
-	websocketManager := websocket.NewWebSocketManager(logger)
+	dataService, err := services.NewDataService(logger, cfg)
+	if err != nil {
+		log.Fatalf("Error creating data service: %v", err)
+	}
+	appMetrics := metrics.New()
+	websocketManager := websocket.NewWebSocketManager(logger, cfg.WebSocket, cfg.JSONNaming, cfg.JSONNumbers, appMetrics)
+
+	// Admin actions are recorded across restarts when a file is configured
+	auditLog, err := audit.Open(cfg.AuditLogFile)
+	if err != nil {
+		log.Fatalf("Error opening audit log: %v", err)
+	}
+	defer auditLog.Close()
 
 	// Create handlers
-	httpHandler := handlers.NewHTTPHandler(logger, dataService)
-	wsHandler := handlers.NewWebSocketHandler(logger, dataService, websocketManager)
+	httpHandler := handlers.NewHTTPHandler(logger, dataService, websocketManager, appMetrics, logHub, auditLog, cfg)
+	wsHandler := handlers.NewWebSocketHandler(logger, dataService, websocketManager, cfg)
+
+	// Background workers stop when this context is cancelled
+	appCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Push finalized candles to the webhook, if one is configured
+	if cfg.CandleWebhook.URL != "" {
+		candleWebhook := services.NewWebhookCandleHook(logger, cfg.CandleWebhook)
+		go candleWebhook.Run(appCtx)
+		dataService.SetCandleHook(candleWebhook)
+	}
 
 	// Initialize trading pairs
 	dataService.InitializeTradingPairs()
 
+	// Drop subscribers that stopped responding without closing their connection
+	go dataService.RunSubscriberReaper(appCtx, cfg.ReaperInterval, cfg.SubscriberIdleTimeout)
+
 	// Create router
 	router := mux.NewRouter()
 
@@ -48,10 +90,10 @@ func main() {
 
 	// Configure CORS
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-API-Key"},
+		AllowCredentials: cfg.CORSAllowCredentials,
 	})
 
 	// Wrap router in CORS middleware
@@ -80,11 +122,16 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWorkers()
 
-	// Give 5 seconds to complete current requests
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeoutSeconds*time.Second)
+	// WebSocket draining and in-flight requests share one shutdown budget
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	// Let WebSocket clients reconnect elsewhere before their connections are closed,
+	// srv.Shutdown doesn't wait for hijacked connections
+	websocketManager.Drain(ctx, cfg.WebSocket.DrainTimeout)
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 		return
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionAddPair    = "pair.add"
+	ActionUpdatePair = "pair.update" // An upsert of an existing pair.
+	ActionRemovePair = "pair.remove"
+	ActionVolatility = "pair.volatility"
+	ActionCandles    = "pair.candles" // Candle history replaced.
+	ActionDrain      = "server.drain"
+)
+
+// maxLineSize bounds one entry read back from the log file.
+const maxLineSize = 1 << 20
+
+// Entry is one recorded admin action.
+type Entry struct {
+	ID         int64          `json:"id"`                // Sequence number, starting at 1.
+	Time       int64          `json:"time"`              // Milliseconds since the epoch.
+	Actor      string         `json:"actor"`             // Authenticated caller, anonymous without authentication.
+	RemoteAddr string         `json:"remoteAddr"`        // Client IP of the request.
+	Action     string         `json:"action"`            // One of the Action constants.
+	Target     string         `json:"target,omitempty"`  // Symbol the action applied to, if any.
+	Details    map[string]any `json:"details,omitempty"` // Parameters of the action.
+}
+
+// Log is an append-only record of admin actions. Entries are kept in memory and, with a
+// file, appended to it as JSON lines so they survive restarts.
+type Log struct {
+	mu      sync.RWMutex
+	entries []Entry
+	file    *os.File
+}
+
+// Open loads the entries of the log file at path and appends new ones to it. An empty path
+// keeps the log in memory only.
+func Open(path string) (*Log, error) {
+	l := &Log{}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// load reads the entries already in the file, a missing file is an empty log.
+func (l *Log) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("reading audit log entry %d: %w", len(l.entries)+1, err)
+		}
+		l.entries = append(l.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	return nil
+}
+
+// Record appends an entry, assigning its ID. The entry is kept in memory even when writing
+// it to the file fails.
+func (l *Log) Record(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.ID = 1
+	if n := len(l.entries); n > 0 {
+		entry.ID = l.entries[n-1].ID + 1
+	}
+	l.entries = append(l.entries, entry)
+
+	if l.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// Page returns up to limit entries, newest first, skipping the offset newest ones, and the
+// total number of entries.
+func (l *Log) Page(offset, limit int) ([]Entry, int) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	total := len(l.entries)
+	end := max(total-offset, 0)
+	start := max(end-limit, 0)
+	page := slices.Clone(l.entries[start:end])
+	slices.Reverse(page)
+	return page, total
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
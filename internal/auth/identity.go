@@ -0,0 +1,22 @@
+package auth
+
+import "context"
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Subject string   // Who the caller is, the sub claim of its token.
+	Roles   []string // Roles granted to the caller.
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the authenticated caller.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the authenticated caller of a request, false for anonymous requests.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
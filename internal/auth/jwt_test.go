@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// makeToken encodes header and claims as a compact JWT. With a secret it is signed with
+// HS256, without one the signature segment is empty.
+func makeToken(t *testing.T, header, claims map[string]any, secret string) string {
+	t.Helper()
+
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("encoding token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(header) + "." + encode(claims)
+	if secret == "" {
+		return unsigned + "."
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+	none := map[string]any{"alg": "none"}
+	valid := map[string]any{"sub": "ops", "exp": now.Add(time.Hour).Unix(), "roles": []string{"admin"}}
+
+	tests := []struct {
+		name          string
+		token         string
+		allowUnsigned bool
+		wantErr       error
+	}{
+		{"signed", makeToken(t, hs256, valid, testSecret), false, nil},
+		{"signed with another secret", makeToken(t, hs256, valid, "fedcba9876543210fedcba9876543210"), false, ErrInvalidSignature},
+		{"claims changed after signing", tamper(t, makeToken(t, hs256, valid, testSecret)), false, ErrInvalidSignature},
+		{"signature missing", makeToken(t, hs256, valid, ""), false, ErrInvalidSignature},
+		{"expired", makeToken(t, hs256, map[string]any{"sub": "ops", "exp": now.Add(-time.Minute).Unix()}, testSecret), false, ErrTokenExpired},
+		{"expired within clock skew", makeToken(t, hs256, map[string]any{"sub": "ops", "exp": now.Add(-10 * time.Second).Unix()}, testSecret), false, nil},
+		{"not yet valid", makeToken(t, hs256, map[string]any{"sub": "ops", "exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}, testSecret), false, ErrTokenNotYetValid},
+		{"no expiry", makeToken(t, hs256, map[string]any{"sub": "ops"}, testSecret), false, ErrMissingExpiry},
+		{"no subject", makeToken(t, hs256, map[string]any{"exp": now.Add(time.Hour).Unix()}, testSecret), false, ErrMissingSubject},
+		{"unsigned outside dev mode", makeToken(t, none, valid, ""), false, ErrUnsupportedAlgorithm},
+		{"unsigned in dev mode", makeToken(t, none, map[string]any{"sub": "dev"}, ""), true, nil},
+		{"unsigned with a signature", makeToken(t, none, valid, testSecret), true, ErrMalformedToken},
+		{"other algorithm", makeToken(t, map[string]any{"alg": "RS256"}, valid, testSecret), false, ErrUnsupportedAlgorithm},
+		{"two segments", "eyJhbGciOiJIUzI1NiJ9.e30", false, ErrMalformedToken},
+		{"header not base64", "!!." + "e30.", false, ErrMalformedToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := NewVerifier(testSecret, tt.allowUnsigned).Verify(tt.token, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject == "" {
+				t.Error("Verify returned claims without a subject")
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutSecret(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := makeToken(t, map[string]any{"alg": "HS256"}, map[string]any{"sub": "ops", "exp": now.Add(time.Hour).Unix()}, "")
+
+	// An empty secret would make every empty-key signature valid, so HS256 is refused outright
+	if _, err := NewVerifier("", false).Verify(token, now); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Verify error = %v, want %v", err, ErrUnsupportedAlgorithm)
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := makeToken(t, map[string]any{"alg": "HS256"},
+		map[string]any{"sub": "ops", "exp": now.Add(time.Hour).Unix(), "roles": []string{"admin", "read"}}, testSecret)
+
+	claims, err := NewVerifier(testSecret, false).Verify(token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "ops" || len(claims.Roles) != 2 || claims.Roles[0] != "admin" {
+		t.Errorf("claims = %+v, want subject ops with roles admin and read", claims)
+	}
+}
+
+// tamper swaps the claims of a signed token for a different subject, keeping the signature.
+func tamper(t *testing.T, token string) string {
+	t.Helper()
+
+	forged := makeToken(t, map[string]any{"alg": "HS256", "typ": "JWT"}, map[string]any{"sub": "root", "exp": 4102444800}, "")
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+}
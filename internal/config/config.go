@@ -0,0 +1,723 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// Default configuration values.
+const (
+	defaultReaperInterval        = 30 * time.Second      // How often idle subscribers are checked.
+	defaultSubscriberIdleTimeout = 90 * time.Second      // Inactivity after which a subscriber is dropped.
+	defaultCandleInterval        = 5 * time.Minute       // Period covered by one candle.
+	defaultHistoryBudget         = time.Second           // Time one pair's generated history may take.
+	defaultStalePairThreshold    = 10 * time.Second      // Age of the last tick after which a pair counts as stalled.
+	defaultBlockTimeout          = 50 * time.Millisecond // Bounded so one slow client can't stall a pair's ticks.
+	defaultBacklogTimeout        = 5 * time.Second       // Full queue duration before a client is disconnected.
+	defaultShutdownTimeout       = 5 * time.Second       // Total time to drain connections and finish requests.
+	defaultDrainTimeout          = 2 * time.Second       // Part of the shutdown budget WebSocket clients get to leave.
+	defaultRetryAfterBase        = 5 * time.Second       // Reconnect delay suggested to clients sent away.
+	defaultRetryAfterJitter      = 5 * time.Second       // Random extra delay spreading their reconnects.
+	defaultWebhookQueueSize      = 256                   // Finalized candles waiting for webhook delivery.
+	defaultWebhookTimeout        = 5 * time.Second       // Time limit of one webhook request.
+	defaultWebhookMaxRetries     = 5                     // Retries of a failed webhook delivery.
+	defaultMaxCandleQueryRange   = 7 * 24 * time.Hour    // Widest startTime/endTime span of one candle query.
+	defaultStaticCacheMaxAge     = 365 * 24 * time.Hour  // Browser cache lifetime of fingerprinted frontend assets.
+	defaultMaxInflightPerIP      = 32                    // Concurrent API requests of one client address.
+
+	// Volatility regimes, probabilities are per price tick.
+	defaultCalmToVolatileProbability = 0.002 // On average ~4 minutes of calm at 500ms ticks.
+	defaultVolatileToCalmProbability = 0.01  // On average ~50 seconds of turbulence.
+	defaultCalmMultiplier            = 0.5
+	defaultVolatileMultiplier        = 3.0
+
+	// Send queue capacity per WebSocket connection and its allowed range.
+	defaultSendQueueSize = 64
+	MinSendQueueSize     = 8    // Smaller queues overflow on the updates of a single tick.
+	MaxSendQueueSize     = 4096 // Larger queues only delay noticing a dead client and cost memory.
+
+	// defaultMaxMessageSize fits a bulk subscribe of a few hundred symbols.
+	defaultMaxMessageSize = 4096
+
+	// defaultSlowConsumerThreshold disconnects a client after 100 full-queue broadcasts in a
+	// row, about 50 seconds for a single pair at 500ms ticks.
+	defaultSlowConsumerThreshold = 100
+
+	// Control messages per second and subscriptions per WebSocket connection, generous for
+	// a chart UI and small enough that one client can't keep the server busy.
+	defaultMaxControlRate   = 20
+	defaultMaxSubscriptions = 200
+
+	// Momentum thresholds, in percent of price change.
+	defaultMomentumFlatThreshold   = 0.5 // Moves this small or smaller are flat.
+	defaultMomentumStrongThreshold = 3.0 // Moves this large or larger are strong.
+
+	// MinCandleInterval is the shortest candle period, in simulated and in real time. Prices
+	// tick five times per candle, shorter candles would flood subscribers.
+	MinCandleInterval = 100 * time.Millisecond
+
+	// MaxSimulationSpeed bounds the speed multiplier, 100x already ticks prices every 5ms.
+	MaxSimulationSpeed = 100
+
+	// defaultMaxPairs bounds the pairs simulated at once, each runs its own goroutine and history.
+	defaultMaxPairs = 50
+
+	// HoursPerDay is the number of weights in a volume profile, one per UTC hour.
+	HoursPerDay = 24
+)
+
+// Initial prices of the default trading pairs.
+const (
+	btcInitialPrice = 95000.0
+	ethInitialPrice = 3500.0
+	solInitialPrice = 180.0
+	bnbInitialPrice = 600.0
+	xrpInitialPrice = 0.55
+)
+
+// SymbolPattern matches valid trading pair symbols (e.g. BTCUSDT).
+var SymbolPattern = regexp.MustCompile(`^[A-Z0-9]{2,20}$`)
+
+// PairConfig is a trading pair simulated from startup.
+type PairConfig struct {
+	Symbol       string
+	InitialPrice float64
+}
+
+// DefaultPairs returns the pairs simulated when PAIRS is not set.
+func DefaultPairs() []PairConfig {
+	return []PairConfig{
+		{Symbol: "BTCUSDT", InitialPrice: btcInitialPrice},
+		{Symbol: "ETHUSDT", InitialPrice: ethInitialPrice},
+		{Symbol: "SOLUSDT", InitialPrice: solInitialPrice},
+		{Symbol: "BNBUSDT", InitialPrice: bnbInitialPrice},
+		{Symbol: "XRPUSDT", InitialPrice: xrpInitialPrice},
+	}
+}
+
+// RegimeConfig describes the optional calm/volatile regime model of the simulator.
+type RegimeConfig struct {
+	Enabled                   bool    // Whether pairs switch between regimes at all.
+	CalmToVolatileProbability float64 // Chance per tick to leave the calm regime.
+	VolatileToCalmProbability float64 // Chance per tick to leave the volatile regime.
+	CalmMultiplier            float64 // Price variation multiplier while calm.
+	VolatileMultiplier        float64 // Price variation multiplier while volatile.
+}
+
+// MomentumConfig holds the price change thresholds pairs are classified by, in percent.
+type MomentumConfig struct {
+	FlatThreshold   float64 // Changes within ±FlatThreshold are flat.
+	StrongThreshold float64 // Changes of at least ±StrongThreshold are strong.
+}
+
+// Ways to handle a candle query whose time range exceeds the maximum.
+const (
+	QueryRangeReject = "reject" // Answer 400 with the allowed maximum.
+	QueryRangeClamp  = "clamp"  // Serve the most recent window of the maximum span.
+)
+
+// Clocks the simulation can take candle times from.
+const (
+	SimulationClockWall      = "wall"      // Follow the system clock, steps included.
+	SimulationClockMonotonic = "monotonic" // Advance from startup by the monotonic clock, immune to steps.
+)
+
+// Time zones candle boundaries are aligned in.
+const (
+	CandleAlignmentUTC   = "utc"   // Midnight UTC, the same on every server.
+	CandleAlignmentLocal = "local" // Midnight in the server's local time zone.
+)
+
+// How candle volume is counted.
+const (
+	VolumeModeCandle     = "candle"     // Volume traded within the candle.
+	VolumeModeCumulative = "cumulative" // Running total of the session (UTC day) up to the candle's end.
+)
+
+// CandleWebhookConfig configures the webhook finalized candles are POSTed to.
+type CandleWebhookConfig struct {
+	URL        string        // Endpoint receiving candles, empty disables the webhook.
+	QueueSize  int           // Candles buffered for delivery, newer ones are dropped when full.
+	Timeout    time.Duration // Time limit of one request.
+	MaxRetries int           // Retries of a failed delivery before the candle is given up.
+}
+
+// MinJWTSecretLength is the shortest accepted HS256 signing key, the size of its hash output.
+const MinJWTSecretLength = 32
+
+// JWTConfig configures the validation of bearer tokens.
+type JWTConfig struct {
+	Secret  string // HS256 signing key, empty disables signed tokens.
+	DevMode bool   // Accept unsigned tokens (alg none), for local testing only.
+}
+
+// Enabled reports whether bearer tokens are accepted at all.
+func (j JWTConfig) Enabled() bool {
+	return j.Secret != "" || j.DevMode
+}
+
+// Roles a caller can hold, granted by the scopes of its API key or the roles claim of its token.
+const (
+	RoleAdmin = "admin" // Admin endpoints and adding or removing pairs.
+)
+
+// Backpressure policies for WebSocket clients whose send queue is full.
+const (
+	BackpressureDropOldest = "dropOldest" // Discard the oldest queued update to make room.
+	BackpressureDisconnect = "disconnect" // Drop updates, close the connection once the backlog persists.
+	BackpressureBlock      = "block"      // Wait for room up to a timeout, then drop the update.
+)
+
+// WebSocketConfig holds the delivery settings of WebSocket connections.
+type WebSocketConfig struct {
+	Backpressure   string        // Default policy, one of the Backpressure constants.
+	BlockTimeout   time.Duration // How long the block policy waits for queue space.
+	BacklogTimeout time.Duration // How long the disconnect policy tolerates a full queue.
+	DrainTimeout   time.Duration // Grace period for clients to disconnect on shutdown.
+	SendQueueSize  int           // Updates buffered per connection before backpressure kicks in.
+	MaxMessageRate int           // Update frames per second per connection, 0 for no limit.
+	MaxMessageSize int           // Largest message in bytes a client may send, larger ones close the connection.
+	Welcome        bool          // Whether every connection opens with a welcome frame.
+
+	// MaxControlRate and MaxSubscriptions bound what a client may ask of the server, 0 for no limit.
+	MaxControlRate   int // Control messages per second.
+	MaxSubscriptions int // Subscriptions per connection across channels.
+
+	// RetryAfterBase and RetryAfterJitter make up the reconnect delay suggested to clients
+	// that are refused or closed while the server drains: the base plus up to the jitter.
+	RetryAfterBase   time.Duration
+	RetryAfterJitter time.Duration
+
+	// SlowConsumerThreshold is the number of broadcasts in a row that may find a client's
+	// queue full before it is disconnected, whatever the policy; 0 disables the check.
+	SlowConsumerThreshold int
+}
+
+// Price models the simulator can use.
+const (
+	PriceModelRandomWalk = "random"
+	PriceModelGBM        = "gbm"
+)
+
+// GBMAsset holds the annualized parameters of one asset in the GBM price model.
+type GBMAsset struct {
+	Symbol     string  `json:"symbol"`
+	Drift      float64 `json:"drift"`      // Annualized drift, e.g. 0.05 for +5% a year.
+	Volatility float64 `json:"volatility"` // Annualized volatility, e.g. 0.6 for 60%.
+}
+
+// GBMConfig configures the correlated geometric Brownian motion price model.
+type GBMConfig struct {
+	Assets      []GBMAsset  `json:"assets"`
+	Correlation [][]float64 `json:"correlation"` // Row i, column j: correlation between assets i and j.
+	TimeScale   float64     `json:"timeScale"`   // Simulated seconds per real second, 0 means 1.
+}
+
+// Config holds the runtime configuration of the server.
+type Config struct {
+	Pairs                 []PairConfig      // Pairs simulated from startup.
+	MaxPairs              int               // Most pairs simulated at once, those added at runtime included.
+	SymbolAliases         map[string]string // Alternative symbol accepted in requests, mapped to the pair's symbol.
+	ReaperInterval        time.Duration     // Interval between subscriber reaper runs.
+	SubscriberIdleTimeout time.Duration     // Maximum time without client activity before a subscriber is reaped.
+	CandleInterval        time.Duration     // Period of one candle, used for history generation and live rollover.
+	CandleIntervals       []time.Duration   // Additional intervals aggregated from base candles, e.g. 15m or 1h.
+	HistoryBudget         time.Duration     // Time allowed to generate one pair's history, 0 for no limit.
+	StalePairThreshold    time.Duration     // Readiness fails when a pair hasn't ticked for longer than this.
+	MaxCandleQueryRange   time.Duration     // Widest time range one candle query may ask for.
+	CandleQueryRangeMode  string            // What happens to wider queries, one of the QueryRange constants.
+	SimulationSpeed       float64           // How much faster than real time the simulation runs.
+	SimulationClock       string            // Source of candle times, one of the SimulationClock constants.
+	Regime                RegimeConfig      // Volatility regime switching.
+	PriceModel            string            // Price model name, one of the PriceModel constants.
+	MarketCorrelation     float64           // Correlation of random walk pairs through a shared market factor.
+	GBM                   *GBMConfig        // GBM model parameters, set when PriceModel is gbm.
+	VolumeProfile         []float64         // Volume weight per UTC hour of the day.
+	VolumeMode            string            // Per-candle or cumulative volume, one of the VolumeMode constants.
+	CandleAlignment       string            // Time zone of candle boundaries, one of the CandleAlignment constants.
+	Momentum              MomentumConfig    // Thresholds of the momentum classification of pairs.
+	WebSocket             WebSocketConfig   // WebSocket delivery settings.
+	ShutdownTimeout       time.Duration     // Budget for a graceful shutdown, WebSocket draining included.
+	AdminEnabled          bool              // Whether the /api/admin endpoints and those changing pairs are served.
+	JSONNaming            string            // Key style of REST and WebSocket JSON, one of the naming styles.
+	JSONNumbers           string            // Number format of REST and WebSocket JSON, one of the naming number formats.
+	CORSAllowedOrigins    []string          // Origins allowed to call the API, "*" for any.
+	CORSAllowCredentials  bool              // Whether browsers may send credentials cross-origin.
+	StaticCacheMaxAge     time.Duration     // Cache lifetime of fingerprinted static assets, 0 disables caching headers.
+	MaxInflightPerIP      int               // Concurrent API requests allowed per client IP, 0 for no limit.
+	AuditLogFile          string            // JSON lines file admin actions are appended to, empty keeps them in memory.
+
+	CandleWebhook CandleWebhookConfig // Delivery of finalized candles to an HTTP endpoint.
+
+	// APIKeys maps each API key to the scopes it grants. Empty disables API key checks.
+	APIKeys map[string][]string
+
+	JWT JWTConfig // Bearer token validation.
+}
+
+// Load reads the configuration from environment variables, falling back to defaults.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Pairs:                 DefaultPairs(),
+		MaxPairs:              defaultMaxPairs,
+		ReaperInterval:        defaultReaperInterval,
+		SubscriberIdleTimeout: defaultSubscriberIdleTimeout,
+		CandleInterval:        defaultCandleInterval,
+		HistoryBudget:         defaultHistoryBudget,
+		StalePairThreshold:    defaultStalePairThreshold,
+		MaxCandleQueryRange:   defaultMaxCandleQueryRange,
+		CandleQueryRangeMode:  QueryRangeReject,
+		SimulationSpeed:       1,
+		SimulationClock:       SimulationClockWall,
+		VolumeMode:            VolumeModeCandle,
+		CandleAlignment:       CandleAlignmentUTC,
+		Regime: RegimeConfig{
+			Enabled:                   false,
+			CalmToVolatileProbability: defaultCalmToVolatileProbability,
+			VolatileToCalmProbability: defaultVolatileToCalmProbability,
+			CalmMultiplier:            defaultCalmMultiplier,
+			VolatileMultiplier:        defaultVolatileMultiplier,
+		},
+		Momentum: MomentumConfig{
+			FlatThreshold:   defaultMomentumFlatThreshold,
+			StrongThreshold: defaultMomentumStrongThreshold,
+		},
+		CandleWebhook: CandleWebhookConfig{
+			QueueSize:  defaultWebhookQueueSize,
+			Timeout:    defaultWebhookTimeout,
+			MaxRetries: defaultWebhookMaxRetries,
+		},
+		PriceModel:         PriceModelRandomWalk,
+		VolumeProfile:      uniformVolumeProfile(),
+		CORSAllowedOrigins: []string{"*"},
+		StaticCacheMaxAge:  defaultStaticCacheMaxAge,
+		MaxInflightPerIP:   defaultMaxInflightPerIP,
+		ShutdownTimeout:    defaultShutdownTimeout,
+		JSONNaming:         naming.StyleCamel,
+		JSONNumbers:        naming.NumbersDefault,
+		WebSocket: WebSocketConfig{
+			Backpressure:   BackpressureDropOldest,
+			BlockTimeout:   defaultBlockTimeout,
+			BacklogTimeout: defaultBacklogTimeout,
+			DrainTimeout:   defaultDrainTimeout,
+			SendQueueSize:  defaultSendQueueSize,
+			MaxMessageSize: defaultMaxMessageSize,
+			Welcome:        true,
+
+			MaxControlRate:   defaultMaxControlRate,
+			MaxSubscriptions: defaultMaxSubscriptions,
+
+			RetryAfterBase:   defaultRetryAfterBase,
+			RetryAfterJitter: defaultRetryAfterJitter,
+
+			SlowConsumerThreshold: defaultSlowConsumerThreshold,
+		},
+	}
+
+	if err := loadPairs(cfg); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv("MAX_PAIRS", &cfg.MaxPairs); err != nil {
+		return nil, err
+	}
+	if err := loadSymbolAliases(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadAPIKeys(cfg); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("REAPER_INTERVAL", &cfg.ReaperInterval); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("SUBSCRIBER_IDLE_TIMEOUT", &cfg.SubscriberIdleTimeout); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("CANDLE_INTERVAL", &cfg.CandleInterval); err != nil {
+		return nil, err
+	}
+	if err := loadCandleIntervals(cfg); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("CANDLE_GENERATION_BUDGET", &cfg.HistoryBudget); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("STALE_PAIR_THRESHOLD", &cfg.StalePairThreshold); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("MAX_CANDLE_QUERY_RANGE", &cfg.MaxCandleQueryRange); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("STATIC_CACHE_MAX_AGE", &cfg.StaticCacheMaxAge); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv("REST_MAX_INFLIGHT_PER_IP", &cfg.MaxInflightPerIP); err != nil {
+		return nil, err
+	}
+	cfg.AuditLogFile = os.Getenv("AUDIT_LOG_FILE")
+	if value := os.Getenv("CANDLE_QUERY_RANGE_MODE"); value != "" {
+		cfg.CandleQueryRangeMode = value
+	}
+	if err := floatFromEnv("SIMULATION_SPEED", &cfg.SimulationSpeed); err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("SIMULATION_CLOCK"); value != "" {
+		cfg.SimulationClock = value
+	}
+	if value := os.Getenv("VOLUME_MODE"); value != "" {
+		cfg.VolumeMode = value
+	}
+	if value := os.Getenv("CANDLE_ALIGNMENT"); value != "" {
+		cfg.CandleAlignment = value
+	}
+	if err := loadRegime(&cfg.Regime); err != nil {
+		return nil, err
+	}
+	if err := loadPriceModel(cfg); err != nil {
+		return nil, err
+	}
+	if err := loadVolumeProfile(cfg); err != nil {
+		return nil, err
+	}
+	if err := floatFromEnv("MOMENTUM_FLAT_THRESHOLD", &cfg.Momentum.FlatThreshold); err != nil {
+		return nil, err
+	}
+	if err := floatFromEnv("MOMENTUM_STRONG_THRESHOLD", &cfg.Momentum.StrongThreshold); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv("SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout); err != nil {
+		return nil, err
+	}
+	if err := loadWebSocket(&cfg.WebSocket); err != nil {
+		return nil, err
+	}
+	if err := loadCandleWebhook(&cfg.CandleWebhook); err != nil {
+		return nil, err
+	}
+	cfg.JWT.Secret = os.Getenv("JWT_SECRET")
+	if err := boolFromEnv("JWT_DEV_MODE", &cfg.JWT.DevMode); err != nil {
+		return nil, err
+	}
+	if err := boolFromEnv("ADMIN_ENABLED", &cfg.AdminEnabled); err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("JSON_NAMING"); value != "" {
+		cfg.JSONNaming = value
+	}
+	if value := os.Getenv("JSON_NUMBERS"); value != "" {
+		cfg.JSONNumbers = value
+	}
+	if value := os.Getenv("CORS_ALLOWED_ORIGINS"); value != "" {
+		cfg.CORSAllowedOrigins = splitList(value)
+	}
+	if err := boolFromEnv("CORS_ALLOW_CREDENTIALS", &cfg.CORSAllowCredentials); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadPairs reads the pairs to simulate from PAIRS, a comma separated list of SYMBOL or
+// SYMBOL:PRICE entries. The price may be left out for the default pairs.
+func loadPairs(cfg *Config) error {
+	value := os.Getenv("PAIRS")
+	if value == "" {
+		return nil
+	}
+
+	defaults := make(map[string]float64)
+	for _, pair := range DefaultPairs() {
+		defaults[pair.Symbol] = pair.InitialPrice
+	}
+
+	cfg.Pairs = nil
+	for _, entry := range splitList(value) {
+		symbol, priceText, hasPrice := strings.Cut(entry, ":")
+		pair := PairConfig{Symbol: symbol, InitialPrice: defaults[symbol]}
+		if hasPrice {
+			price, err := strconv.ParseFloat(priceText, 64)
+			if err != nil {
+				return fmt.Errorf("invalid PAIRS: price of %s: %w", symbol, err)
+			}
+			pair.InitialPrice = price
+		}
+		cfg.Pairs = append(cfg.Pairs, pair)
+	}
+	return nil
+}
+
+// loadSymbolAliases reads SYMBOL_ALIASES, a comma separated list of ALIAS=SYMBOL entries.
+func loadSymbolAliases(cfg *Config) error {
+	value := os.Getenv("SYMBOL_ALIASES")
+	if value == "" {
+		return nil
+	}
+
+	cfg.SymbolAliases = make(map[string]string)
+	for _, entry := range splitList(value) {
+		alias, symbol, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid SYMBOL_ALIASES entry %q, expected ALIAS=SYMBOL", entry)
+		}
+		if _, dup := cfg.SymbolAliases[alias]; dup {
+			return fmt.Errorf("invalid SYMBOL_ALIASES: %s is given more than once", alias)
+		}
+		cfg.SymbolAliases[alias] = symbol
+	}
+	return nil
+}
+
+// loadAPIKeys reads API_KEYS, a comma separated list of KEY=SCOPE entries. A key with
+// several scopes lists them separated by |, e.g. KEY=admin|trade.
+func loadAPIKeys(cfg *Config) error {
+	value := os.Getenv("API_KEYS")
+	if value == "" {
+		return nil
+	}
+
+	cfg.APIKeys = make(map[string][]string)
+	for _, entry := range splitList(value) {
+		key, scopes, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return errors.New("invalid API_KEYS entry, expected KEY=SCOPE")
+		}
+		if _, dup := cfg.APIKeys[key]; dup {
+			return errors.New("invalid API_KEYS: a key is given more than once")
+		}
+		cfg.APIKeys[key] = strings.Split(scopes, "|")
+	}
+	return nil
+}
+
+// loadRegime reads the regime settings from the environment.
+func loadRegime(regime *RegimeConfig) error {
+	if err := boolFromEnv("REGIME_SWITCHING", &regime.Enabled); err != nil {
+		return err
+	}
+	if err := floatFromEnv("REGIME_CALM_TO_VOLATILE_PROBABILITY", &regime.CalmToVolatileProbability); err != nil {
+		return err
+	}
+	if err := floatFromEnv("REGIME_VOLATILE_TO_CALM_PROBABILITY", &regime.VolatileToCalmProbability); err != nil {
+		return err
+	}
+	if err := floatFromEnv("REGIME_CALM_MULTIPLIER", &regime.CalmMultiplier); err != nil {
+		return err
+	}
+	return floatFromEnv("REGIME_VOLATILE_MULTIPLIER", &regime.VolatileMultiplier)
+}
+
+// loadWebSocket reads the WebSocket delivery settings from the environment.
+func loadWebSocket(ws *WebSocketConfig) error {
+	if value := os.Getenv("WS_BACKPRESSURE_POLICY"); value != "" {
+		ws.Backpressure = value
+	}
+	if err := durationFromEnv("WS_BLOCK_TIMEOUT", &ws.BlockTimeout); err != nil {
+		return err
+	}
+	if err := durationFromEnv("WS_BACKLOG_TIMEOUT", &ws.BacklogTimeout); err != nil {
+		return err
+	}
+	if err := durationFromEnv("WS_DRAIN_TIMEOUT", &ws.DrainTimeout); err != nil {
+		return err
+	}
+	if err := intFromEnv("WS_SEND_QUEUE_SIZE", &ws.SendQueueSize); err != nil {
+		return err
+	}
+	if err := intFromEnv("WS_MAX_MESSAGE_RATE", &ws.MaxMessageRate); err != nil {
+		return err
+	}
+	if err := intFromEnv("WS_MAX_MESSAGE_SIZE", &ws.MaxMessageSize); err != nil {
+		return err
+	}
+	if err := intFromEnv("WS_MAX_CONTROL_RATE", &ws.MaxControlRate); err != nil {
+		return err
+	}
+	if err := intFromEnv("WS_MAX_SUBSCRIPTIONS", &ws.MaxSubscriptions); err != nil {
+		return err
+	}
+	if err := boolFromEnv("WS_WELCOME", &ws.Welcome); err != nil {
+		return err
+	}
+	if err := durationFromEnv("WS_RETRY_AFTER_BASE", &ws.RetryAfterBase); err != nil {
+		return err
+	}
+	if err := durationFromEnv("WS_RETRY_AFTER_JITTER", &ws.RetryAfterJitter); err != nil {
+		return err
+	}
+	return intFromEnv("WS_SLOW_CONSUMER_THRESHOLD", &ws.SlowConsumerThreshold)
+}
+
+// loadCandleWebhook reads the candle webhook settings from the environment.
+func loadCandleWebhook(webhook *CandleWebhookConfig) error {
+	webhook.URL = os.Getenv("CANDLE_WEBHOOK_URL")
+	if err := intFromEnv("CANDLE_WEBHOOK_QUEUE_SIZE", &webhook.QueueSize); err != nil {
+		return err
+	}
+	if err := durationFromEnv("CANDLE_WEBHOOK_TIMEOUT", &webhook.Timeout); err != nil {
+		return err
+	}
+	return intFromEnv("CANDLE_WEBHOOK_MAX_RETRIES", &webhook.MaxRetries)
+}
+
+// loadPriceModel reads the price model selection, the random walk correlation and, for GBM, its parameter file.
+func loadPriceModel(cfg *Config) error {
+	if value := os.Getenv("PRICE_MODEL"); value != "" {
+		cfg.PriceModel = value
+	}
+	if err := floatFromEnv("MARKET_CORRELATION", &cfg.MarketCorrelation); err != nil {
+		return err
+	}
+
+	path := os.Getenv("GBM_CONFIG_FILE")
+	if cfg.PriceModel != PriceModelGBM || path == "" {
+		return nil
+	}
+
+	gbm, err := loadGBMConfig(path)
+	if err != nil {
+		return err
+	}
+	cfg.GBM = gbm
+	return nil
+}
+
+// loadGBMConfig reads GBM parameters from a JSON file.
+func loadGBMConfig(path string) (*GBMConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading GBM config: %w", err)
+	}
+
+	var gbm GBMConfig
+	if err = json.Unmarshal(data, &gbm); err != nil {
+		return nil, fmt.Errorf("parsing GBM config: %w", err)
+	}
+
+	if gbm.TimeScale == 0 {
+		gbm.TimeScale = 1
+	}
+
+	return &gbm, nil
+}
+
+// loadCandleIntervals reads the comma separated aggregation intervals from CANDLE_INTERVALS.
+func loadCandleIntervals(cfg *Config) error {
+	value := os.Getenv("CANDLE_INTERVALS")
+	if value == "" {
+		return nil
+	}
+
+	for _, part := range splitList(value) {
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return fmt.Errorf("invalid CANDLE_INTERVALS: %w", err)
+		}
+		cfg.CandleIntervals = append(cfg.CandleIntervals, d)
+	}
+	return nil
+}
+
+// uniformVolumeProfile weighs every hour equally.
+func uniformVolumeProfile() []float64 {
+	profile := make([]float64, HoursPerDay)
+	for i := range profile {
+		profile[i] = 1
+	}
+	return profile
+}
+
+// loadVolumeProfile reads comma separated hourly weights from VOLUME_PROFILE.
+func loadVolumeProfile(cfg *Config) error {
+	value := os.Getenv("VOLUME_PROFILE")
+	if value == "" {
+		return nil
+	}
+
+	parts := splitList(value)
+	profile := make([]float64, len(parts))
+	for hour, part := range parts {
+		weight, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return fmt.Errorf("invalid VOLUME_PROFILE: weight for hour %d: %w", hour, err)
+		}
+		profile[hour] = weight
+	}
+	cfg.VolumeProfile = profile
+	return nil
+}
+
+// splitList splits a comma separated value and trims the items.
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// durationFromEnv overrides target with the value of the environment variable, if set.
+func durationFromEnv(name string, target *time.Duration) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = d
+	return nil
+}
+
+// floatFromEnv overrides target with the value of the environment variable, if set.
+func floatFromEnv(name string, target *float64) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = f
+	return nil
+}
+
+// intFromEnv overrides target with the value of the environment variable, if set.
+func intFromEnv(name string, target *int) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = n
+	return nil
+}
+
+// boolFromEnv overrides target with the value of the environment variable, if set.
+func boolFromEnv(name string, target *bool) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*target = b
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCandleIntervals(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []time.Duration
+		wantErr string
+	}{
+		{"unset", "", nil, ""},
+		{"list", "5m, 15m,1h", []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour}, ""},
+		{"invalid", "5m,quarter", nil, "invalid CANDLE_INTERVALS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CANDLE_INTERVALS", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !slices.Equal(cfg.CandleIntervals, tt.want) {
+				t.Errorf("intervals %v, want %v", cfg.CandleIntervals, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []PairConfig
+		wantErr string
+	}{
+		{"unset", "", DefaultPairs(), ""},
+		{"default prices", "BTCUSDT,ETHUSDT", DefaultPairs()[:2], ""},
+		{"custom price", "BTCUSDT, DOGEUSDT:0.2", []PairConfig{DefaultPairs()[0], {Symbol: "DOGEUSDT", InitialPrice: 0.2}}, ""},
+		{"default overridden", "BTCUSDT:1000", []PairConfig{{Symbol: "BTCUSDT", InitialPrice: 1000}}, ""},
+		{"unknown without price", "DOGEUSDT", []PairConfig{{Symbol: "DOGEUSDT"}}, ""},
+		{"invalid price", "DOGEUSDT:cheap", nil, "invalid PAIRS: price of DOGEUSDT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAIRS", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !slices.Equal(cfg.Pairs, tt.want) {
+				t.Errorf("pairs %v, want %v", cfg.Pairs, tt.want)
+			}
+		})
+	}
+}
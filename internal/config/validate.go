@@ -0,0 +1,396 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// day is the span candle intervals must divide, candles are aligned to midnight.
+const day = 24 * time.Hour
+
+// Validate checks the configuration for invalid values and combinations.
+// All problems are reported at once so they can be fixed in one go.
+func (c *Config) Validate() error {
+	var errs []error
+
+	errs = append(errs, c.validatePairs()...)
+	if c.MaxPairs < len(c.Pairs) {
+		errs = append(errs, fmt.Errorf("MAX_PAIRS (%d) must allow at least the %d pairs in PAIRS", c.MaxPairs, len(c.Pairs)))
+	}
+	errs = append(errs, c.validateSymbolAliases()...)
+	errs = append(errs, c.validateAPIKeys()...)
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d bytes, got %d",
+			MinJWTSecretLength, len(c.JWT.Secret)))
+	}
+	if c.AdminEnabled && len(c.APIKeys) == 0 && !c.JWT.Enabled() {
+		errs = append(errs, errors.New(
+			"ADMIN_ENABLED requires API_KEYS, JWT_SECRET or JWT_DEV_MODE, otherwise the admin endpoints are open to anyone"))
+	}
+
+	if c.ReaperInterval <= 0 {
+		errs = append(errs, fmt.Errorf("REAPER_INTERVAL must be positive, got %s", c.ReaperInterval))
+	}
+	if c.SubscriberIdleTimeout <= c.ReaperInterval {
+		errs = append(errs, fmt.Errorf(
+			"SUBSCRIBER_IDLE_TIMEOUT (%s) must be longer than REAPER_INTERVAL (%s), "+
+				"otherwise healthy clients are reaped before their pong arrives",
+			c.SubscriberIdleTimeout, c.ReaperInterval))
+	}
+
+	switch {
+	case c.CandleInterval < MinCandleInterval:
+		errs = append(errs, fmt.Errorf("CANDLE_INTERVAL must be at least %s, got %s", MinCandleInterval, c.CandleInterval))
+	case day%c.CandleInterval != 0:
+		errs = append(errs, fmt.Errorf(
+			"CANDLE_INTERVAL (%s) must divide 24h evenly so candle boundaries line up every day", c.CandleInterval))
+	}
+
+	errs = append(errs, c.validateCandleIntervals()...)
+	if c.HistoryBudget < 0 {
+		errs = append(errs, fmt.Errorf("CANDLE_GENERATION_BUDGET must not be negative, got %s", c.HistoryBudget))
+	}
+
+	if c.StalePairThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("STALE_PAIR_THRESHOLD must be positive, got %s", c.StalePairThreshold))
+	}
+
+	if c.MaxCandleQueryRange <= 0 {
+		errs = append(errs, fmt.Errorf("MAX_CANDLE_QUERY_RANGE must be positive, got %s", c.MaxCandleQueryRange))
+	}
+	if c.StaticCacheMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("STATIC_CACHE_MAX_AGE must not be negative, got %s", c.StaticCacheMaxAge))
+	}
+	if c.MaxInflightPerIP < 0 {
+		errs = append(errs, fmt.Errorf("REST_MAX_INFLIGHT_PER_IP must not be negative, got %d", c.MaxInflightPerIP))
+	}
+	if c.CandleQueryRangeMode != QueryRangeReject && c.CandleQueryRangeMode != QueryRangeClamp {
+		errs = append(errs, fmt.Errorf("CANDLE_QUERY_RANGE_MODE must be %s or %s, got %q",
+			QueryRangeReject, QueryRangeClamp, c.CandleQueryRangeMode))
+	}
+
+	switch {
+	case c.SimulationSpeed <= 0 || c.SimulationSpeed > MaxSimulationSpeed:
+		errs = append(errs, fmt.Errorf("SIMULATION_SPEED must be within (0,%d], got %g",
+			MaxSimulationSpeed, c.SimulationSpeed))
+	case c.CandleInterval >= MinCandleInterval &&
+		time.Duration(float64(c.CandleInterval)/c.SimulationSpeed) < MinCandleInterval:
+		errs = append(errs, fmt.Errorf("CANDLE_INTERVAL (%s) at SIMULATION_SPEED %g rolls candles faster than every %s",
+			c.CandleInterval, c.SimulationSpeed, MinCandleInterval))
+	}
+
+	if c.SimulationClock != SimulationClockWall && c.SimulationClock != SimulationClockMonotonic {
+		errs = append(errs, fmt.Errorf("SIMULATION_CLOCK must be %s or %s, got %q",
+			SimulationClockWall, SimulationClockMonotonic, c.SimulationClock))
+	}
+
+	if c.CandleAlignment != CandleAlignmentUTC && c.CandleAlignment != CandleAlignmentLocal {
+		errs = append(errs, fmt.Errorf("CANDLE_ALIGNMENT must be %s or %s, got %q",
+			CandleAlignmentUTC, CandleAlignmentLocal, c.CandleAlignment))
+	}
+
+	if c.VolumeMode != VolumeModeCandle && c.VolumeMode != VolumeModeCumulative {
+		errs = append(errs, fmt.Errorf("VOLUME_MODE must be %s or %s, got %q",
+			VolumeModeCandle, VolumeModeCumulative, c.VolumeMode))
+	}
+
+	errs = append(errs, c.Regime.validate()...)
+	errs = append(errs, c.WebSocket.validate()...)
+
+	// Draining happens inside the shutdown budget, HTTP requests need some of it too
+	if c.WebSocket.DrainTimeout >= c.ShutdownTimeout {
+		errs = append(errs, fmt.Errorf("WS_DRAIN_TIMEOUT (%s) must be shorter than SHUTDOWN_TIMEOUT (%s)",
+			c.WebSocket.DrainTimeout, c.ShutdownTimeout))
+	}
+	errs = append(errs, c.CandleWebhook.validate()...)
+	errs = append(errs, c.validatePriceModel()...)
+	errs = append(errs, c.validateVolumeProfile()...)
+
+	if c.Momentum.FlatThreshold < 0 {
+		errs = append(errs, fmt.Errorf("MOMENTUM_FLAT_THRESHOLD must not be negative, got %g", c.Momentum.FlatThreshold))
+	}
+	if c.Momentum.StrongThreshold <= c.Momentum.FlatThreshold {
+		errs = append(errs, fmt.Errorf("MOMENTUM_STRONG_THRESHOLD (%g) must be greater than MOMENTUM_FLAT_THRESHOLD (%g)",
+			c.Momentum.StrongThreshold, c.Momentum.FlatThreshold))
+	}
+
+	if c.JSONNaming != naming.StyleCamel && c.JSONNaming != naming.StyleSnake {
+		errs = append(errs, fmt.Errorf("JSON_NAMING must be %s or %s, got %q",
+			naming.StyleCamel, naming.StyleSnake, c.JSONNaming))
+	}
+	if c.JSONNumbers != naming.NumbersDefault && c.JSONNumbers != naming.NumbersPlain {
+		errs = append(errs, fmt.Errorf("JSON_NUMBERS must be %s or %s, got %q",
+			naming.NumbersDefault, naming.NumbersPlain, c.JSONNumbers))
+	}
+
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		errs = append(errs, errors.New(
+			"CORS_ALLOW_CREDENTIALS cannot be combined with a wildcard CORS_ALLOWED_ORIGINS, "+
+				"browsers reject credentialed requests to '*'; list the allowed origins explicitly"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePairs checks that the pair list is usable: valid symbols, no duplicates and a
+// price for every pair that is not one of the defaults.
+func (c *Config) validatePairs() []error {
+	if len(c.Pairs) == 0 {
+		return []error{errors.New("PAIRS must list at least one pair")}
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(c.Pairs))
+	for _, pair := range c.Pairs {
+		switch {
+		case !SymbolPattern.MatchString(pair.Symbol):
+			errs = append(errs, fmt.Errorf("PAIRS entry %q must be 2-20 uppercase letters or digits", pair.Symbol))
+		case seen[pair.Symbol]:
+			errs = append(errs, fmt.Errorf("PAIRS lists %s more than once", pair.Symbol))
+		case pair.InitialPrice <= 0:
+			errs = append(errs, fmt.Errorf(
+				"PAIRS entry %s needs a positive initial price, unknown pairs must be given as %s:PRICE",
+				pair.Symbol, pair.Symbol))
+		}
+		seen[pair.Symbol] = true
+	}
+	return errs
+}
+
+// validateAPIKeys checks that every API key grants known scopes. Keys are left out of the
+// messages so they don't end up in logs.
+func (c *Config) validateAPIKeys() []error {
+	var errs []error
+	for _, scopes := range c.APIKeys {
+		for _, scope := range scopes {
+			if scope != RoleAdmin {
+				errs = append(errs, fmt.Errorf("API_KEYS scope must be %s, got %q", RoleAdmin, scope))
+			}
+		}
+	}
+	return errs
+}
+
+// validateSymbolAliases checks that every alias is a valid symbol of its own, points to a
+// configured pair and doesn't shadow one.
+func (c *Config) validateSymbolAliases() []error {
+	pairs := make(map[string]bool, len(c.Pairs))
+	for _, pair := range c.Pairs {
+		pairs[pair.Symbol] = true
+	}
+
+	var errs []error
+	for _, alias := range slices.Sorted(maps.Keys(c.SymbolAliases)) {
+		symbol := c.SymbolAliases[alias]
+		switch {
+		case !SymbolPattern.MatchString(alias):
+			errs = append(errs, fmt.Errorf("SYMBOL_ALIASES alias %q must be 2-20 uppercase letters or digits", alias))
+		case pairs[alias]:
+			errs = append(errs, fmt.Errorf("SYMBOL_ALIASES alias %s collides with a pair of the same name", alias))
+		case !pairs[symbol]:
+			errs = append(errs, fmt.Errorf("SYMBOL_ALIASES alias %s points to %s, which is not in PAIRS", alias, symbol))
+		}
+	}
+	return errs
+}
+
+// validateCandleIntervals checks that every aggregation interval is built from whole base candles.
+func (c *Config) validateCandleIntervals() []error {
+	if c.CandleInterval < MinCandleInterval {
+		return nil // Reported with the base interval
+	}
+
+	var errs []error
+	seen := make(map[time.Duration]bool, len(c.CandleIntervals))
+	for _, interval := range c.CandleIntervals {
+		switch {
+		case interval <= c.CandleInterval || interval%c.CandleInterval != 0:
+			errs = append(errs, fmt.Errorf("CANDLE_INTERVALS entry %s must be a larger multiple of CANDLE_INTERVAL (%s)",
+				interval, c.CandleInterval))
+		case day%interval != 0:
+			errs = append(errs, fmt.Errorf("CANDLE_INTERVALS entry %s must divide 24h evenly", interval))
+		case seen[interval]:
+			errs = append(errs, fmt.Errorf("CANDLE_INTERVALS lists %s more than once", interval))
+		}
+		seen[interval] = true
+	}
+	return errs
+}
+
+// validate checks the regime probabilities and multipliers.
+func (r *RegimeConfig) validate() []error {
+	var errs []error
+	if r.CalmToVolatileProbability < 0 || r.CalmToVolatileProbability > 1 {
+		errs = append(errs, fmt.Errorf(
+			"REGIME_CALM_TO_VOLATILE_PROBABILITY must be within [0,1], got %g", r.CalmToVolatileProbability))
+	}
+	if r.VolatileToCalmProbability < 0 || r.VolatileToCalmProbability > 1 {
+		errs = append(errs, fmt.Errorf(
+			"REGIME_VOLATILE_TO_CALM_PROBABILITY must be within [0,1], got %g", r.VolatileToCalmProbability))
+	}
+	if r.CalmMultiplier <= 0 {
+		errs = append(errs, fmt.Errorf("REGIME_CALM_MULTIPLIER must be positive, got %g", r.CalmMultiplier))
+	}
+	if r.VolatileMultiplier <= 0 {
+		errs = append(errs, fmt.Errorf("REGIME_VOLATILE_MULTIPLIER must be positive, got %g", r.VolatileMultiplier))
+	}
+	return errs
+}
+
+// IsBackpressurePolicy reports whether policy names a known backpressure policy.
+func IsBackpressurePolicy(policy string) bool {
+	switch policy {
+	case BackpressureDropOldest, BackpressureDisconnect, BackpressureBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// validate checks the WebSocket delivery settings.
+func (ws *WebSocketConfig) validate() []error {
+	var errs []error
+	if !IsBackpressurePolicy(ws.Backpressure) {
+		errs = append(errs, fmt.Errorf("WS_BACKPRESSURE_POLICY must be %s, %s or %s, got %q",
+			BackpressureDropOldest, BackpressureDisconnect, BackpressureBlock, ws.Backpressure))
+	}
+	if ws.BlockTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("WS_BLOCK_TIMEOUT must be positive, got %s", ws.BlockTimeout))
+	}
+	if ws.BacklogTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("WS_BACKLOG_TIMEOUT must be positive, got %s", ws.BacklogTimeout))
+	}
+	if ws.SendQueueSize < MinSendQueueSize || ws.SendQueueSize > MaxSendQueueSize {
+		errs = append(errs, fmt.Errorf("WS_SEND_QUEUE_SIZE must be within [%d,%d], got %d",
+			MinSendQueueSize, MaxSendQueueSize, ws.SendQueueSize))
+	}
+	if ws.SlowConsumerThreshold < 0 {
+		errs = append(errs, fmt.Errorf("WS_SLOW_CONSUMER_THRESHOLD must not be negative, got %d",
+			ws.SlowConsumerThreshold))
+	}
+	if ws.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("WS_DRAIN_TIMEOUT must not be negative, got %s", ws.DrainTimeout))
+	}
+	if ws.MaxMessageRate < 0 {
+		errs = append(errs, fmt.Errorf("WS_MAX_MESSAGE_RATE must not be negative, got %d", ws.MaxMessageRate))
+	}
+	if ws.MaxControlRate < 0 {
+		errs = append(errs, fmt.Errorf("WS_MAX_CONTROL_RATE must not be negative, got %d", ws.MaxControlRate))
+	}
+	if ws.MaxSubscriptions < 0 {
+		errs = append(errs, fmt.Errorf("WS_MAX_SUBSCRIPTIONS must not be negative, got %d", ws.MaxSubscriptions))
+	}
+	if ws.MaxMessageSize <= 0 {
+		errs = append(errs, fmt.Errorf("WS_MAX_MESSAGE_SIZE must be positive, got %d", ws.MaxMessageSize))
+	}
+	if ws.RetryAfterBase < 0 {
+		errs = append(errs, fmt.Errorf("WS_RETRY_AFTER_BASE must not be negative, got %s", ws.RetryAfterBase))
+	}
+	if ws.RetryAfterJitter < 0 {
+		errs = append(errs, fmt.Errorf("WS_RETRY_AFTER_JITTER must not be negative, got %s", ws.RetryAfterJitter))
+	}
+	return errs
+}
+
+// validate checks the webhook URL and delivery limits. They are checked even when the
+// webhook is disabled, so a typo doesn't wait until it's turned on.
+func (w *CandleWebhookConfig) validate() []error {
+	var errs []error
+	if w.URL != "" {
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("CANDLE_WEBHOOK_URL must be an http or https URL, got %q", w.URL))
+		}
+	}
+	if w.QueueSize <= 0 {
+		errs = append(errs, fmt.Errorf("CANDLE_WEBHOOK_QUEUE_SIZE must be positive, got %d", w.QueueSize))
+	}
+	if w.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("CANDLE_WEBHOOK_TIMEOUT must be positive, got %s", w.Timeout))
+	}
+	if w.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("CANDLE_WEBHOOK_MAX_RETRIES must not be negative, got %d", w.MaxRetries))
+	}
+	return errs
+}
+
+// validatePriceModel checks the model name and, for GBM, the shape of its parameters.
+func (c *Config) validatePriceModel() []error {
+	// A single shared factor can only pull pairs together, not apart
+	if c.MarketCorrelation < 0 || c.MarketCorrelation > 1 {
+		return []error{fmt.Errorf("MARKET_CORRELATION must be within [0,1], got %g", c.MarketCorrelation)}
+	}
+
+	switch c.PriceModel {
+	case PriceModelRandomWalk:
+		return nil
+	case PriceModelGBM:
+		if c.GBM == nil {
+			return []error{errors.New("GBM_CONFIG_FILE is required when PRICE_MODEL is gbm")}
+		}
+		return c.GBM.validate()
+	default:
+		return []error{fmt.Errorf("PRICE_MODEL must be %s or %s, got %q",
+			PriceModelRandomWalk, PriceModelGBM, c.PriceModel)}
+	}
+}
+
+// validate checks that the correlation matrix matches the assets and is a valid correlation matrix.
+func (g *GBMConfig) validate() []error {
+	n := len(g.Assets)
+	if n == 0 {
+		return []error{errors.New("GBM config must list at least one asset")}
+	}
+	if len(g.Correlation) != n {
+		return []error{fmt.Errorf("GBM correlation matrix must have %d rows, got %d", n, len(g.Correlation))}
+	}
+
+	var errs []error
+	if g.TimeScale <= 0 {
+		errs = append(errs, fmt.Errorf("GBM timeScale must be positive, got %g", g.TimeScale))
+	}
+	for i, asset := range g.Assets {
+		if asset.Volatility < 0 {
+			errs = append(errs, fmt.Errorf("GBM volatility of %s must not be negative", asset.Symbol))
+		}
+		if len(g.Correlation[i]) != n {
+			errs = append(errs, fmt.Errorf("GBM correlation row %d must have %d values, got %d",
+				i, n, len(g.Correlation[i])))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for i, row := range g.Correlation {
+		if row[i] != 1 {
+			errs = append(errs, fmt.Errorf("GBM correlation[%d][%d] must be 1", i, i))
+		}
+		for j, rho := range row {
+			if rho < -1 || rho > 1 || rho != g.Correlation[j][i] {
+				errs = append(errs, fmt.Errorf("GBM correlation[%d][%d] must be within [-1,1] and symmetric", i, j))
+			}
+		}
+	}
+	return errs
+}
+
+// validateVolumeProfile checks that there is one non-negative weight per hour.
+func (c *Config) validateVolumeProfile() []error {
+	if len(c.VolumeProfile) != HoursPerDay {
+		return []error{fmt.Errorf("VOLUME_PROFILE needs %d hourly weights, got %d", HoursPerDay, len(c.VolumeProfile))}
+	}
+
+	var errs []error
+	for hour, weight := range c.VolumeProfile {
+		if weight < 0 {
+			errs = append(errs, fmt.Errorf("VOLUME_PROFILE weight for hour %d must not be negative, got %g", hour, weight))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// defaultConfig returns the configuration Load builds from an empty environment.
+func defaultConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	return cfg
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if err := defaultConfig(t).Validate(); err != nil {
+		t.Fatalf("default configuration is invalid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr string // Part of the expected message, empty when the change is valid.
+	}{
+		{"no pairs", func(c *Config) { c.Pairs = nil }, "PAIRS must list at least one pair"},
+		{"invalid pair symbol", func(c *Config) { c.Pairs[0].Symbol = "btc-usdt" }, `PAIRS entry "btc-usdt" must be 2-20 uppercase letters or digits`},
+		{"pair listed twice", func(c *Config) { c.Pairs = append(c.Pairs, c.Pairs[0]) }, "PAIRS lists BTCUSDT more than once"},
+		{"unknown pair without price", func(c *Config) { c.Pairs = append(c.Pairs, PairConfig{Symbol: "DOGEUSDT"}) },
+			"PAIRS entry DOGEUSDT needs a positive initial price, unknown pairs must be given as DOGEUSDT:PRICE"},
+		{"pair limit", func(c *Config) { c.MaxPairs = len(c.Pairs) - 1 }, "MAX_PAIRS (4) must allow at least the 5 pairs in PAIRS"},
+		{"pair limit reached by PAIRS", func(c *Config) { c.MaxPairs = len(c.Pairs) }, ""},
+		{"reaper interval", func(c *Config) { c.ReaperInterval = 0 }, "REAPER_INTERVAL must be positive"},
+		{"idle timeout within reaper interval", func(c *Config) {
+			c.ReaperInterval = time.Minute
+			c.SubscriberIdleTimeout = time.Minute
+		}, "SUBSCRIBER_IDLE_TIMEOUT (1m0s) must be longer than REAPER_INTERVAL (1m0s)"},
+		{"candle interval too short", func(c *Config) { c.CandleInterval = time.Millisecond }, "CANDLE_INTERVAL must be at least"},
+		{"candle interval not dividing a day", func(c *Config) { c.CandleInterval = 7 * time.Minute }, "must divide 24h evenly"},
+		{"sub-minute candle interval", func(c *Config) { c.CandleInterval = 30 * time.Second; c.CandleIntervals = nil }, ""},
+		{"aggregation intervals", func(c *Config) { c.CandleIntervals = []time.Duration{15 * time.Minute, time.Hour} }, ""},
+		{"aggregation interval not larger", func(c *Config) { c.CandleIntervals = []time.Duration{c.CandleInterval} },
+			"must be a larger multiple of CANDLE_INTERVAL"},
+		{"aggregation interval not a multiple", func(c *Config) { c.CandleIntervals = []time.Duration{90 * time.Second} },
+			"must be a larger multiple"},
+		{"aggregation interval not dividing a day", func(c *Config) { c.CandleIntervals = []time.Duration{7 * time.Hour} },
+			"CANDLE_INTERVALS entry 7h0m0s must divide 24h evenly"},
+		{"aggregation interval twice", func(c *Config) { c.CandleIntervals = []time.Duration{time.Hour, time.Hour} },
+			"CANDLE_INTERVALS lists 1h0m0s more than once"},
+		{"negative history budget", func(c *Config) { c.HistoryBudget = -time.Second }, "CANDLE_GENERATION_BUDGET must not be negative"},
+		{"static cache max age", func(c *Config) { c.StaticCacheMaxAge = -time.Second }, "STATIC_CACHE_MAX_AGE must not be negative"},
+		{"static caching disabled", func(c *Config) { c.StaticCacheMaxAge = 0 }, ""},
+		{"candle alignment", func(c *Config) { c.CandleAlignment = "EST" }, "CANDLE_ALIGNMENT must be"},
+		{"local candle alignment", func(c *Config) { c.CandleAlignment = CandleAlignmentLocal }, ""},
+		{"query range", func(c *Config) { c.MaxCandleQueryRange = 0 }, "MAX_CANDLE_QUERY_RANGE must be positive"},
+		{"query range mode", func(c *Config) { c.CandleQueryRangeMode = "truncate" }, "CANDLE_QUERY_RANGE_MODE must be"},
+		{"simulation speed", func(c *Config) { c.SimulationSpeed = 0 }, "SIMULATION_SPEED must be within"},
+		{"simulation clock", func(c *Config) { c.SimulationClock = "atomic" }, `SIMULATION_CLOCK must be wall or monotonic, got "atomic"`},
+		{"regime probability", func(c *Config) { c.Regime.CalmToVolatileProbability = 1.5 }, "REGIME_CALM_TO_VOLATILE_PROBABILITY"},
+		{"backpressure policy", func(c *Config) { c.WebSocket.Backpressure = "ignore" }, "WS_BACKPRESSURE_POLICY"},
+		{"max message size", func(c *Config) { c.WebSocket.MaxMessageSize = 0 }, "WS_MAX_MESSAGE_SIZE must be positive"},
+		{"control rate", func(c *Config) { c.WebSocket.MaxControlRate = -1 }, "WS_MAX_CONTROL_RATE must not be negative"},
+		{"subscription cap", func(c *Config) { c.WebSocket.MaxSubscriptions = -1 }, "WS_MAX_SUBSCRIPTIONS must not be negative"},
+		{"send queue size", func(c *Config) { c.WebSocket.SendQueueSize = 1 }, "WS_SEND_QUEUE_SIZE must be within"},
+		{"drain longer than shutdown", func(c *Config) {
+			c.WebSocket.DrainTimeout = time.Minute
+			c.ShutdownTimeout = time.Second
+		}, "WS_DRAIN_TIMEOUT (1m0s) must be shorter than SHUTDOWN_TIMEOUT (1s)"},
+		{"webhook URL", func(c *Config) { c.CandleWebhook.URL = "ftp://example.com" }, "CANDLE_WEBHOOK_URL must be an http or https URL"},
+		{"webhook URL without host", func(c *Config) { c.CandleWebhook.URL = "https://" }, "CANDLE_WEBHOOK_URL must be an http or https URL"},
+		{"webhook", func(c *Config) { c.CandleWebhook.URL = "https://example.com/candles" }, ""},
+		{"webhook queue size", func(c *Config) { c.CandleWebhook.QueueSize = 0 }, "CANDLE_WEBHOOK_QUEUE_SIZE must be positive"},
+		{"webhook timeout", func(c *Config) { c.CandleWebhook.Timeout = 0 }, "CANDLE_WEBHOOK_TIMEOUT must be positive"},
+		{"webhook retries", func(c *Config) { c.CandleWebhook.MaxRetries = -1 }, "CANDLE_WEBHOOK_MAX_RETRIES must not be negative"},
+		{"price model", func(c *Config) { c.PriceModel = "heston" }, "PRICE_MODEL must be"},
+		{"GBM without config", func(c *Config) { c.PriceModel = PriceModelGBM; c.GBM = nil }, "GBM_CONFIG_FILE is required"},
+		{"volume profile length", func(c *Config) { c.VolumeProfile = c.VolumeProfile[:12] }, "VOLUME_PROFILE needs 24 hourly weights, got 12"},
+		{"negative flat threshold", func(c *Config) { c.Momentum.FlatThreshold = -1 }, "MOMENTUM_FLAT_THRESHOLD must not be negative"},
+		{"momentum thresholds", func(c *Config) { c.Momentum.StrongThreshold = c.Momentum.FlatThreshold }, "MOMENTUM_STRONG_THRESHOLD"},
+		{"number format", func(c *Config) { c.JSONNumbers = "scientific" }, "JSON_NUMBERS must be"},
+		{"plain numbers", func(c *Config) { c.JSONNumbers = naming.NumbersPlain }, ""},
+		{"admin without credentials", func(c *Config) { c.AdminEnabled = true }, "ADMIN_ENABLED requires API_KEYS, JWT_SECRET or JWT_DEV_MODE"},
+		{"admin with API keys", func(c *Config) {
+			c.AdminEnabled = true
+			c.APIKeys = map[string][]string{"k1": {RoleAdmin}}
+		}, ""},
+		{"admin with tokens", func(c *Config) { c.AdminEnabled = true; c.JWT.DevMode = true }, ""},
+		{"credentials with wildcard origin", func(c *Config) {
+			c.CORSAllowedOrigins = []string{"*"}
+			c.CORSAllowCredentials = true
+		}, "CORS_ALLOW_CREDENTIALS cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig(t)
+			tt.change(cfg)
+
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("got %v, want no error", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("got no error, want %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := defaultConfig(t)
+	cfg.ReaperInterval = 0
+	cfg.SimulationClock = "atomic"
+	cfg.WebSocket.Backpressure = "ignore"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{"REAPER_INTERVAL", "SIMULATION_CLOCK", "WS_BACKPRESSURE_POLICY"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %s: %v", want, err)
+		}
+	}
+}
+
+func TestGBMConfigValidate(t *testing.T) {
+	asset := func(symbol string) GBMAsset { return GBMAsset{Symbol: symbol, Drift: 0.05, Volatility: 0.6} }
+
+	tests := []struct {
+		name    string
+		gbm     GBMConfig
+		wantErr string
+	}{
+		{"valid", GBMConfig{
+			Assets:      []GBMAsset{asset("BTCUSDT"), asset("ETHUSDT")},
+			Correlation: [][]float64{{1, 0.8}, {0.8, 1}},
+			TimeScale:   1,
+		}, ""},
+		{"no assets", GBMConfig{TimeScale: 1}, "at least one asset"},
+		{"rows", GBMConfig{
+			Assets:      []GBMAsset{asset("BTCUSDT"), asset("ETHUSDT")},
+			Correlation: [][]float64{{1, 0.8}},
+			TimeScale:   1,
+		}, "must have 2 rows, got 1"},
+		{"diagonal", GBMConfig{
+			Assets:      []GBMAsset{asset("BTCUSDT")},
+			Correlation: [][]float64{{0.5}},
+			TimeScale:   1,
+		}, "correlation[0][0] must be 1"},
+		{"asymmetric", GBMConfig{
+			Assets:      []GBMAsset{asset("BTCUSDT"), asset("ETHUSDT")},
+			Correlation: [][]float64{{1, 0.8}, {0.2, 1}},
+			TimeScale:   1,
+		}, "symmetric"},
+		{"time scale", GBMConfig{
+			Assets:      []GBMAsset{asset("BTCUSDT")},
+			Correlation: [][]float64{{1}},
+		}, "timeScale must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.gbm.validate()
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Fatalf("got %v, want no error", errs)
+				}
+				return
+			}
+			if len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("got %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/audit"
+)
+
+// defaultDrainWindow is how long a drain spreads out closing connections when no window is given.
+const defaultDrainWindow = 30 * time.Second
+
+// DrainHandler puts the server in drain mode ahead of a rolling deploy: new WebSocket
+// connections and new pairs are refused, and open connections are closed gradually over
+// ?window= (default 30s) so a load balancer can move clients elsewhere.
+func (h *HTTPHandler) DrainHandler(w http.ResponseWriter, r *http.Request) {
+	window := defaultDrainWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		var err error
+		if window, err = time.ParseDuration(value); err != nil || window < 0 {
+			http.Error(w, "window must be a non-negative duration such as 30s", http.StatusBadRequest)
+			return
+		}
+	}
+
+	remaining := h.websocketManager.BeginDrain(window)
+	h.logger.Info("Drain requested", "window", window, "connections", remaining)
+	h.recordAudit(r, audit.ActionDrain, "", map[string]any{"window": window.String()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	body := map[string]any{
+		"draining":    true,
+		"connections": remaining,
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Error encoding drain status", "error", err)
+	}
+}
+
+// ConnectionsHandler lists the open WebSocket connections with what they watch and how
+// much they received, for live debugging. Client addresses are reported without port.
+func (h *HTTPHandler) ConnectionsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.websocketManager.ConnectionInfos()); err != nil {
+		h.logger.Error("Error encoding connections", "error", err)
+	}
+}
+
+// logHeartbeatInterval is how often an idle log stream sends a comment to keep proxies from
+// closing it.
+const logHeartbeatInterval = 15 * time.Second
+
+// LogsHandler streams the server's log records as Server-Sent Events, one JSON record per
+// event, for debugging without shell access. Lines a slow client can't take are dropped and
+// reported in a comment.
+func (h *HTTPHandler) LogsHandler(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// The stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := h.logHub.Subscribe()
+	defer h.logHub.Unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(logHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-client.Lines():
+			if dropped := client.TakeDropped(); dropped > 0 {
+				_, err = fmt.Fprintf(w, ": dropped %d lines\n\n", dropped)
+			}
+			if err == nil {
+				_, err = fmt.Fprintf(w, "data: %s\n\n", line)
+			}
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			// Logging here would feed the stream that just failed
+			return
+		}
+	}
+}
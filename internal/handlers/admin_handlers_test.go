@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+// testAdminKey is the API key granting the admin role on servers of adminServer.
+const testAdminKey = "test-admin-key"
+
+// adminServer returns a test server with the admin endpoints enabled, guarded by testAdminKey.
+func adminServer(t *testing.T, configure func(*config.Config)) *testServer {
+	t.Helper()
+
+	return newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminEnabled = true
+		cfg.APIKeys = map[string][]string{testAdminKey: {config.RoleAdmin}}
+		if configure != nil {
+			configure(cfg)
+		}
+	})
+}
+
+func TestConnections(t *testing.T) {
+	s := adminServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+
+	first := s.dial(t, "/ws/ETHUSDT")
+	readFrame(t, first, messageTypeWelcome)
+	time.Sleep(10 * time.Millisecond) // Distinct connection times
+	second := s.dial(t, "/ws/ETHUSDT")
+	send(t, second, `{"action":"subscribe","symbol":"BTCUSDT"}`)
+	readFrame(t, second, messageTypeSubscribed)
+
+	rec := s.serve(adminRequest(http.MethodGet, "/api/admin/connections", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var infos []websocket.ConnectionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("decoding connections: %v", err)
+	}
+
+	if len(infos) != 2 {
+		t.Fatalf("got %d connections, want 2", len(infos))
+	}
+	if !slices.Equal(infos[0].Symbols, []string{"ETHUSDT"}) || !slices.Equal(infos[1].Symbols, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("symbols %v and %v, want the oldest connection first, symbols sorted", infos[0].Symbols, infos[1].Symbols)
+	}
+	for _, info := range infos {
+		if info.ID == "" || info.RemoteAddr != "127.0.0.1" || info.MessagesSent == 0 {
+			t.Errorf("incomplete connection info %+v", info)
+		}
+	}
+}
+
+// protectedRoutes are the endpoints requiring the admin role.
+var protectedRoutes = []struct {
+	method string
+	path   string
+}{
+	{http.MethodPost, "/api/pairs"},
+	{http.MethodPut, "/api/pairs/BTCUSDT/volatility"},
+	{http.MethodPut, "/api/pairs/BTCUSDT/candles"},
+	{http.MethodDelete, "/api/pairs/BTCUSDT"},
+	{http.MethodPost, "/api/admin/drain"},
+	{http.MethodGet, "/api/admin/connections"},
+	{http.MethodGet, "/api/admin/logs"},
+	{http.MethodGet, "/api/admin/audit"},
+}
+
+func TestAdminEndpointsRequireCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+	}{
+		{"API key configured", nil},
+		{"nothing configured", func(cfg *config.Config) { cfg.APIKeys = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := adminServer(t, tt.configure)
+			s.addPair(t, "BTCUSDT", 50000)
+
+			for _, route := range protectedRoutes {
+				rec := s.serve(httptest.NewRequest(route.method, route.path, nil))
+				if rec.Code != http.StatusUnauthorized {
+					t.Errorf("%s %s: status = %d, want 401 without credentials", route.method, route.path, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminEndpointsDisabled(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.AdminEnabled = false })
+	s.addPair(t, "BTCUSDT", 50000)
+
+	for _, route := range protectedRoutes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set(apiKeyHeader, testAdminKey)
+		if rec := s.serve(req); rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status = %d, want it not served while disabled", route.method, route.path, rec.Code)
+		}
+	}
+}
+
+func TestRemovePairKeepsOtherSubscriptions(t *testing.T) {
+	s := adminServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+
+	both := s.dial(t, "/ws/BTCUSDT")
+	send(t, both, `{"action":"subscribe","symbol":"ETHUSDT"}`)
+	readFrame(t, both, messageTypeSubscribed)
+	only := s.dial(t, "/ws/ETHUSDT")
+	readFrame(t, only, messageTypeWelcome)
+
+	rec := s.serve(adminRequest(http.MethodDelete, "/api/pairs/ETHUSDT", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	if frame := readFrame(t, both, "symbol_removed"); frame["symbol"] != "ETHUSDT" {
+		t.Errorf("got %v, want a symbol_removed frame for ETHUSDT", frame)
+	}
+	// Updates of the remaining pair keep coming. A broadcast already under way when the pair
+	// was removed may still deliver one of its updates, those are skipped
+	if err := both.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("setting read deadline: %v", err)
+	}
+	for {
+		var update map[string]any
+		if err := both.ReadJSON(&update); err != nil {
+			t.Fatalf("waiting for a BTCUSDT update: %v", err)
+		}
+		if update["symbol"] == "BTCUSDT" && update["type"] == nil {
+			break
+		}
+	}
+
+	if err := only.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("setting read deadline: %v", err)
+	}
+	for {
+		_, _, err := only.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !gorilla.IsCloseError(err, gorilla.CloseNormalClosure) {
+			t.Fatalf("connection of the removed pair ended with %v, want a normal close", err)
+		}
+		break
+	}
+}
+
+func TestLogsStream(t *testing.T) {
+	s := adminServer(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.server.URL+"/api/admin/logs", nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set(apiKeyHeader, testAdminKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("opening log stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got %d %s, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// The handler subscribed before sending the headers, so the line reaches it
+	if _, err := s.handler.logHub.Write([]byte("{\"msg\":\"hello\"}\n")); err != nil {
+		t.Fatalf("writing log line: %v", err)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	select {
+	case line := <-lines:
+		if line != `data: {"msg":"hello"}` {
+			t.Errorf("got %q, want the log line as an event", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+}
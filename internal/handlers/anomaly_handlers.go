@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+)
+
+// Defaults and bounds of the volume anomaly query.
+const (
+	defaultAnomalyWindow = 50  // Candles forming the baseline of each candle.
+	defaultAnomalySigma  = 3.0 // Standard deviations above the mean that count as an anomaly.
+	minAnomalyWindow     = 2   // A standard deviation needs at least two values.
+)
+
+// GetVolumeAnomaliesHandler returns the candles of a pair whose volume spikes above the rolling
+// mean of the ?window= candles before them by at least ?sigma= standard deviations.
+func (h *HTTPHandler) GetVolumeAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	window, err := int64QueryParam(r, "window")
+	if err != nil || (window != nil && *window < minAnomalyWindow) {
+		http.Error(w, fmt.Sprintf("window must be an integer of at least %d", minAnomalyWindow), http.StatusBadRequest)
+		return
+	}
+	sigma, err := floatQueryParam(r, "sigma")
+	if err != nil || (sigma != nil && *sigma <= 0) {
+		http.Error(w, "sigma must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	windowSize, threshold := defaultAnomalyWindow, defaultAnomalySigma
+	if window != nil {
+		windowSize = int(*window)
+	}
+	if sigma != nil {
+		threshold = *sigma
+	}
+
+	anomalies, err := h.dataService.VolumeAnomalies(r.Context(), symbol, windowSize, threshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Anomaly request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(anomalies); encodeErr != nil {
+		h.logger.Error("Error encoding volume anomalies", "error", encodeErr)
+	}
+}
@@ -1,79 +1,600 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/sand/crypto-trading-app/backend/internal/audit"
+	"github.com/sand/crypto-trading-app/backend/internal/auth"
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/logstream"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
 	"github.com/sand/crypto-trading-app/backend/internal/services"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
 )
 
+// addPairRequest is the body of POST /api/pairs.
+type addPairRequest struct {
+	Symbol       string   `json:"symbol"`
+	InitialPrice *float64 `json:"initialPrice"`
+	Volatility   *float64 `json:"volatility"`
+}
+
+// validate checks the request fields.
+func (req *addPairRequest) validate() error {
+	if !config.SymbolPattern.MatchString(req.Symbol) {
+		return errors.New("symbol must be 2-20 uppercase letters or digits")
+	}
+	if req.InitialPrice != nil && *req.InitialPrice <= 0 {
+		return errors.New("initialPrice must be positive")
+	}
+	if req.Volatility != nil {
+		return validateVolatility(*req.Volatility)
+	}
+	return nil
+}
+
+// volatilityRequest is the body of PUT /api/pairs/{symbol}/volatility.
+type volatilityRequest struct {
+	Volatility *float64 `json:"volatility"`
+}
+
+// validate checks the volatility is present and within bounds.
+func (req *volatilityRequest) validate() error {
+	if req.Volatility == nil {
+		return errors.New("volatility is required")
+	}
+	return validateVolatility(*req.Volatility)
+}
+
+// validateVolatility checks a volatility set through the API, whether on a new or a running pair.
+func validateVolatility(volatility float64) error {
+	if volatility <= 0 || volatility > services.MaxVolatility {
+		return fmt.Errorf("volatility must be positive and at most %g", services.MaxVolatility)
+	}
+	return nil
+}
+
 type HTTPHandler struct {
-	logger      *slog.Logger
-	dataService *services.DataService
+	logger           *slog.Logger
+	dataService      *services.DataService
+	websocketManager *websocket.Manager
+	metrics          *metrics.Metrics
+	staleThreshold   time.Duration // Pairs not updated within this are stale and make the server unready.
+	naming           string        // JSON key style of responses.
+	numbers          string        // JSON number format of responses.
+	adminEnabled     bool          // Whether the /api/admin endpoints and those changing pairs are served.
+	momentum         config.MomentumConfig
+	maxQueryRange    time.Duration    // Widest startTime/endTime span of a candle query.
+	queryRangeMode   string           // Whether wider candle queries are rejected or clamped.
+	staticMaxAge     time.Duration    // Cache lifetime of fingerprinted frontend assets.
+	logHub           *logstream.Hub   // Source of the admin log stream.
+	inflight         *inflightLimiter // API requests in progress per client IP.
+	apiKeys          apiKeyScopes     // Scopes of the accepted API keys, empty when no key is required.
+	tokens           *auth.Verifier   // Bearer token validation, nil when tokens are not accepted.
+	auditLog         *audit.Log       // Record of admin actions.
 }
 
-func NewHTTPHandler(logger *slog.Logger, dataService *services.DataService) *HTTPHandler {
+func NewHTTPHandler(
+	logger *slog.Logger,
+	dataService *services.DataService,
+	websocketManager *websocket.Manager,
+	m *metrics.Metrics,
+	logHub *logstream.Hub,
+	auditLog *audit.Log,
+	cfg *config.Config,
+) *HTTPHandler {
+	var tokens *auth.Verifier
+	if cfg.JWT.Enabled() {
+		tokens = auth.NewVerifier(cfg.JWT.Secret, cfg.JWT.DevMode)
+	}
+
 	return &HTTPHandler{
-		logger:      logger,
-		dataService: dataService,
+		logger:           logger,
+		dataService:      dataService,
+		websocketManager: websocketManager,
+		metrics:          m,
+		staleThreshold:   cfg.StalePairThreshold,
+		naming:           cfg.JSONNaming,
+		numbers:          cfg.JSONNumbers,
+		adminEnabled:     cfg.AdminEnabled,
+		momentum:         cfg.Momentum,
+		maxQueryRange:    cfg.MaxCandleQueryRange,
+		queryRangeMode:   cfg.CandleQueryRangeMode,
+		staticMaxAge:     cfg.StaticCacheMaxAge,
+		logHub:           logHub,
+		inflight:         newInflightLimiter(cfg.MaxInflightPerIP),
+		apiKeys:          newAPIKeyScopes(cfg.APIKeys),
+		tokens:           tokens,
+		auditLog:         auditLog,
 	}
 }
 
 func (h *HTTPHandler) RegisterRoutes(router *mux.Router) {
+	// The log stream is long-lived and unbuffered, so it skips the request timeout and
+	// response rewriting of the other API endpoints.
+	if h.adminEnabled {
+		logs := h.bearerTokenMiddleware(h.requireRole(config.RoleAdmin, h.LogsHandler))
+		router.Handle("/api/admin/logs", h.instrumentMiddleware(logs)).Methods("GET")
+	}
+
 	// API endpoints.
-	router.HandleFunc("/api/pairs", h.GetTradingPairsHandler).Methods("GET")
-	router.HandleFunc("/api/candles/{symbol}", h.GetCandlesHandler).Methods("GET")
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(h.instrumentMiddleware, h.bearerTokenMiddleware, h.inflightLimitMiddleware, h.namingMiddleware,
+		timeoutMiddleware)
+	api.HandleFunc("/pairs", h.GetTradingPairsHandler).Methods("GET")
+	api.HandleFunc("/pairs/{symbol}/currentbucket", h.GetCurrentBucketHandler).Methods("GET")
+	api.HandleFunc("/candles/{symbol}", h.GetCandlesHandler).Methods("GET")
+	api.HandleFunc("/ticks/{symbol}", h.GetTicksHandler).Methods("GET")
+	api.HandleFunc("/price-history/{symbol}", h.GetPriceHistoryHandler).Methods("GET")
+	api.HandleFunc("/debug/pairs/{symbol}", h.GetPairDebugHandler).Methods("GET")
+	api.HandleFunc("/stats", h.GetStatsHandler).Methods("GET")
+	api.HandleFunc("/meta", h.GetMetaHandler).Methods("GET")
+	if h.adminEnabled {
+		api.Handle("/pairs", h.requireRole(config.RoleAdmin, h.AddTradingPairHandler)).Methods("POST")
+		api.Handle("/pairs/{symbol}/volatility", h.requireRole(config.RoleAdmin, h.SetVolatilityHandler)).Methods("PUT")
+		api.Handle("/pairs/{symbol}/candles", h.requireRole(config.RoleAdmin, h.ReplaceCandlesHandler)).Methods("PUT")
+		api.Handle("/admin/drain", h.requireRole(config.RoleAdmin, h.DrainHandler)).Methods("POST")
+		api.Handle("/admin/connections", h.requireRole(config.RoleAdmin, h.ConnectionsHandler)).Methods("GET")
+		api.Handle("/admin/audit", h.requireRole(config.RoleAdmin, h.AuditHandler)).Methods("GET")
+		api.Handle("/pairs/{symbol}", h.requireRole(config.RoleAdmin, h.RemoveTradingPairHandler)).Methods("DELETE")
+	}
+	api.HandleFunc("/index/{symbol}", h.GetIndexPriceHandler).Methods("GET")
+	api.HandleFunc("/anomalies/{symbol}", h.GetVolumeAnomaliesHandler).Methods("GET")
+	api.HandleFunc("/renko/{symbol}", h.GetRenkoHandler).Methods("GET")
+
+	// Prometheus metrics.
+	router.Handle("/metrics", h.metrics.Handler()).Methods("GET")
+
+	// Readiness probe.
+	router.HandleFunc("/readyz", h.ReadinessHandler).Methods("GET")
 
 	// Static files - register last to avoid intercepting other routes.
 	fs := http.FileServer(http.Dir("./static"))
-	router.PathPrefix("/").Handler(http.StripPrefix("/", fs))
+	router.PathPrefix("/").Handler(http.StripPrefix("/", staticCacheHandler(fs, h.staticMaxAge)))
 }
 
-// GetTradingPairsHandler returns a list of trading pairs.
-func (h *HTTPHandler) GetTradingPairsHandler(w http.ResponseWriter, _ *http.Request) {
-	pairs := make([]map[string]any, 0, len(h.dataService.TradingPairs))
+// GetTradingPairsHandler returns a list of trading pairs, optionally filtered by price
+// change and sorted, e.g. ?sort=priceChange&order=desc for a top movers view.
+func (h *HTTPHandler) GetTradingPairsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePairQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	for _, pair := range h.dataService.TradingPairs {
+	tradingPairs := h.dataService.Pairs()
+	pairs := make([]pairSummary, 0, len(tradingPairs))
+
+	now := time.Now()
+	for _, pair := range tradingPairs {
 		pair.Mutex.RLock()
-		pairData := map[string]any{
-			"symbol":      pair.Symbol,
-			"lastPrice":   pair.LastPrice,
-			"priceChange": pair.PriceChange,
-		}
+		age := now.Sub(pair.LastUpdate)
+		pairs = append(pairs, pairSummary{
+			Symbol:      pair.Symbol,
+			LastPrice:   pair.LastPrice,
+			MarkPrice:   pair.MarkPrice,
+			PriceChange: pair.PriceChange,
+			Momentum:    services.Momentum(pair.PriceChange, h.momentum),
+			LastUpdate:  pair.LastUpdate.UnixMilli(),
+			AgeMs:       age.Milliseconds(),
+			Stale:       age > h.staleThreshold,
+		})
 		pair.Mutex.RUnlock()
-
-		pairs = append(pairs, pairData)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(pairs); err != nil {
+	if err := json.NewEncoder(w).Encode(query.apply(pairs)); err != nil {
 		h.logger.Error("Error encoding trading pairs", "error", err)
 	}
 }
 
+// AddTradingPairHandler creates a trading pair. With ?upsert=true an existing pair has
+// its initial price and volatility updated instead of being rejected with 409.
+func (h *HTTPHandler) AddTradingPairHandler(w http.ResponseWriter, r *http.Request) {
+	if h.websocketManager.Draining() {
+		http.Error(w, "Server is draining", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req addPairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upsert, err := boolQueryParam(r, "upsert")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pair, created, err := h.dataService.AddTradingPair(r.Context(), req.Symbol, req.InitialPrice, req.Volatility, upsert)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairExists):
+			http.Error(w, "Trading pair already exists", http.StatusConflict)
+		case errors.Is(err, services.ErrSymbolIsAlias):
+			http.Error(w, "Symbol is in use as an alias", http.StatusConflict)
+		case errors.Is(err, services.ErrPairLimitReached):
+			http.Error(w, "Trading pair limit reached", http.StatusConflict)
+		case errors.Is(err, services.ErrInitialPriceRequired):
+			http.Error(w, "initialPrice is required for a new pair", http.StatusBadRequest)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	pair.Mutex.RLock()
+	pairData := map[string]any{
+		"symbol":       pair.Symbol,
+		"lastPrice":    pair.LastPrice,
+		"priceChange":  pair.PriceChange,
+		"initialPrice": pair.InitialPrice,
+		"volatility":   pair.Volatility,
+	}
+	pair.Mutex.RUnlock()
+
+	status, action := http.StatusOK, audit.ActionUpdatePair
+	if created {
+		status, action = http.StatusCreated, audit.ActionAddPair
+	}
+	h.recordAudit(r, action, pair.Symbol, map[string]any{
+		"initialPrice": req.InitialPrice,
+		"volatility":   req.Volatility,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if encodeErr := json.NewEncoder(w).Encode(pairData); encodeErr != nil {
+		h.logger.Error("Error encoding trading pair", "error", encodeErr)
+	}
+}
+
+// SetVolatilityHandler changes the volatility of a running pair without restarting its simulation.
+func (h *HTTPHandler) SetVolatilityHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	var req volatilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pair, previous, err := h.dataService.SetVolatility(r.Context(), symbol, *req.Volatility)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Set volatility request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.recordAudit(r, audit.ActionVolatility, pair.Symbol, map[string]any{
+		"volatility": *req.Volatility,
+		"previous":   previous,
+	})
+
+	body := map[string]any{
+		"symbol":             pair.Symbol,
+		"volatility":         *req.Volatility,
+		"previousVolatility": previous,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
+		h.logger.Error("Error encoding volatility", "error", encodeErr)
+	}
+}
+
+// ReplaceCandlesHandler replaces the candle history of a running pair with the candles in the
+// body, for example to reproduce a chart shape. The simulation continues from the last one.
+func (h *HTTPHandler) ReplaceCandlesHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	var candles []models.CandleData
+	if err := json.NewDecoder(r.Body).Decode(&candles); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.dataService.ReplaceCandleData(r.Context(), symbol, candles)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCandles):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Replace candles request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.recordAudit(r, audit.ActionCandles, pair.Symbol, map[string]any{
+		"count": len(candles),
+		"from":  candles[0].Time,
+		"to":    candles[len(candles)-1].Time,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveTradingPairHandler deletes a trading pair. Its WebSocket subscribers are notified
+// and keep their other subscriptions.
+func (h *HTTPHandler) RemoveTradingPairHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	if err := h.dataService.RemoveTradingPair(r.Context(), symbol); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Remove pair request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.recordAudit(r, audit.ActionRemovePair, symbol, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetCandlesHandler returns candle data for a trading pair.
 func (h *HTTPHandler) GetCandlesHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	symbol := vars["symbol"]
 
-	candles, err := h.dataService.GetCandleData(symbol)
+	withDirection, err := boolQueryParam(r, "withDirection")
 	if err != nil {
-		if errors.Is(err, services.ErrTradingPairNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	heikinAshi, err := boolQueryParam(r, "ha")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeFormat := models.TimeFormatMillis
+	if value := r.URL.Query().Get("timeFormat"); value != "" {
+		if !models.IsTimeFormat(value) {
+			http.Error(w, "timeFormat must be millis, seconds or iso", http.StatusBadRequest)
+			return
+		}
+		timeFormat = value
+	}
+
+	interval := h.dataService.BaseInterval()
+	if value := r.URL.Query().Get("interval"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "interval must be a duration such as 15m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	queryRange, err := parseCandleRange(r, h.dataService.Now(), h.maxQueryRange, h.queryRangeMode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	candles, err := h.dataService.GetCandleDataForInterval(r.Context(), symbol, interval)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
 			http.Error(w, "Trading pair not found", http.StatusNotFound)
-		} else {
+		case errors.Is(err, services.ErrUnsupportedInterval):
+			http.Error(w, "Unsupported interval, see /api/meta", http.StatusBadRequest)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			// Client went away, nobody is left to read the response
+			h.logger.Debug("Candle request cancelled", "symbol", symbol)
+		default:
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	// Heikin-Ashi candles depend on all earlier ones, so the range is cut afterwards
+	if heikinAshi {
+		candles = services.HeikinAshi(candles)
+	}
+	if queryRange != nil {
+		candles = queryRange.filter(candles)
+	}
+
+	// Stored candles encode as they are, wrapping is only needed for derived output
+	var body any = candles
+	if withDirection || timeFormat != models.TimeFormatMillis {
+		body = formatCandles(candles, timeFormat, withDirection)
+	}
+
 	h.logger.Info("Sending candles", "count", len(candles), "symbol", symbol)
 	w.Header().Set("Content-Type", "application/json")
-	encodeErr := json.NewEncoder(w).Encode(candles)
+	encodeErr := json.NewEncoder(w).Encode(body)
 	if encodeErr != nil {
 		h.logger.Error("Error encoding candles", "error", encodeErr)
 	}
 }
+
+// GetCurrentBucketHandler returns the candle interval in progress so clients can align their
+// live candle with the server and count down to the rollover.
+func (h *HTTPHandler) GetCurrentBucketHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	bucket, err := h.dataService.CurrentBucket(symbol)
+	if err != nil {
+		if errors.Is(err, services.ErrTradingPairNotFound) {
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(bucket); encodeErr != nil {
+		h.logger.Error("Error encoding current bucket", "error", encodeErr)
+	}
+}
+
+// GetPairDebugHandler returns simulation internals of a trading pair.
+func (h *HTTPHandler) GetPairDebugHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	info, err := h.dataService.DebugInfo(symbol)
+	if err != nil {
+		if errors.Is(err, services.ErrTradingPairNotFound) {
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(info); encodeErr != nil {
+		h.logger.Error("Error encoding debug info", "error", encodeErr)
+	}
+}
+
+// GetMetaHandler describes what the server supports, currently the candle intervals.
+func (h *HTTPHandler) GetMetaHandler(w http.ResponseWriter, _ *http.Request) {
+	intervals := h.dataService.Intervals()
+	names := make([]string, 0, len(intervals))
+	for _, interval := range intervals {
+		names = append(names, services.FormatInterval(interval))
+	}
+
+	meta := map[string]any{
+		"baseInterval": services.FormatInterval(h.dataService.BaseInterval()),
+		"intervals":    names,
+		"speed":        h.dataService.Speed(),
+		"volumeType":   h.dataService.VolumeMode(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meta); err != nil {
+		h.logger.Error("Error encoding meta", "error", err)
+	}
+}
+
+// GetStatsHandler returns a summary of the simulation and its subscribers, with ?window= the
+// price change of every pair over that lookback.
+func (h *HTTPHandler) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	var window time.Duration
+	if value := r.URL.Query().Get("window"); value != "" {
+		var err error
+		if window, err = services.ParseWindow(value); err != nil {
+			http.Error(w, "window must be a positive duration such as 4h or 7d", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.dataService.Stats(r.Context(), window)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Stats request cancelled")
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(stats); encodeErr != nil {
+		h.logger.Error("Error encoding stats", "error", encodeErr)
+	}
+}
+
+// GetIndexPriceHandler returns the reference price of a trading pair.
+func (h *HTTPHandler) GetIndexPriceHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	index, err := h.dataService.IndexPrice(r.Context(), symbol)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Index price request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(index); encodeErr != nil {
+		h.logger.Error("Error encoding index price", "error", encodeErr)
+	}
+}
+
+// ReadinessHandler reports whether every pair is still being simulated.
+// It fails with 503 and lists the stalled pairs when any pair stopped ticking.
+func (h *HTTPHandler) ReadinessHandler(w http.ResponseWriter, _ *http.Request) {
+	stale := h.dataService.StalePairs(h.staleThreshold)
+
+	status := http.StatusOK
+	body := map[string]any{"status": "ready"}
+	if len(stale) > 0 {
+		h.logger.Warn("Readiness check failed, pairs stalled", "symbols", stale)
+		status = http.StatusServiceUnavailable
+		body = map[string]any{"status": "stalled", "stalePairs": stale}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Error("Error encoding readiness", "error", err)
+	}
+}
+
+// boolQueryParam parses an optional boolean query parameter, absent means false.
+func boolQueryParam(r *http.Request, name string) (bool, error) {
+	if !r.URL.Query().Has(name) {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(r.URL.Query().Get(name))
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return value, nil
+}
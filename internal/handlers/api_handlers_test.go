@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+func TestAddPairRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     addPairRequest
+		wantErr bool
+	}{
+		{name: "symbol only", req: addPairRequest{Symbol: "DOGEUSDT"}},
+		{name: "all fields", req: addPairRequest{Symbol: "DOGEUSDT", InitialPrice: ptr(0.2), Volatility: ptr(1.5)}},
+		{name: "largest volatility", req: addPairRequest{Symbol: "DOGEUSDT", Volatility: ptr(10.0)}},
+		{name: "lowercase symbol", req: addPairRequest{Symbol: "dogeusdt"}, wantErr: true},
+		{name: "zero price", req: addPairRequest{Symbol: "DOGEUSDT", InitialPrice: ptr(0.0)}, wantErr: true},
+		{name: "zero volatility", req: addPairRequest{Symbol: "DOGEUSDT", Volatility: ptr(0.0)}, wantErr: true},
+		{name: "volatility above the cap", req: addPairRequest{Symbol: "DOGEUSDT", Volatility: ptr(1e6)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVolatilityRequestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		volatility *float64
+		wantErr    bool
+	}{
+		{name: "in range", volatility: ptr(3.0)},
+		{name: "cap", volatility: ptr(10.0)},
+		{name: "missing", volatility: nil, wantErr: true},
+		{name: "negative", volatility: ptr(-1.0), wantErr: true},
+		{name: "above the cap", volatility: ptr(10.5), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := volatilityRequest{Volatility: tt.volatility}
+			if err := req.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddPairLimitReached(t *testing.T) {
+	srv := adminServer(t, func(cfg *config.Config) { cfg.MaxPairs = 1 })
+	srv.addPair(t, "AAAUSDT", 1)
+
+	rec := srv.serve(adminRequest(http.MethodPost, "/api/pairs",
+		strings.NewReader(`{"symbol":"BBBUSDT","initialPrice":1}`)))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "Trading pair limit reached") {
+		t.Fatalf("got %d %q, want 409 Trading pair limit reached", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+// testJWTSecret signs the tokens of the tests, as long as a real secret must be.
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// signToken returns an HS256 token for subject with the given roles, valid for an hour.
+func signToken(t *testing.T, secret, subject string, roles ...string) string {
+	t.Helper()
+
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("encoding token segment: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	claims := map[string]any{"sub": subject, "exp": time.Now().Add(time.Hour).Unix(), "roles": roles}
+	unsigned := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestRequireRole(t *testing.T) {
+	s := adminServer(t, func(cfg *config.Config) {
+		cfg.APIKeys = map[string][]string{"admin-key": {config.RoleAdmin}, "read-key": {"read"}}
+		cfg.JWT.Secret = testJWTSecret
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"no credentials", "/api/admin/connections", "", "", http.StatusUnauthorized},
+		{"admin key", "/api/admin/connections", apiKeyHeader, "admin-key", http.StatusOK},
+		{"key without the role", "/api/admin/connections", apiKeyHeader, "read-key", http.StatusForbidden},
+		{"unknown key", "/api/admin/connections", apiKeyHeader, "guessed-key", http.StatusUnauthorized},
+		{"admin token", "/api/admin/connections", "Authorization", "Bearer " + signToken(t, testJWTSecret, "ops", config.RoleAdmin), http.StatusOK},
+		{"token without the role", "/api/admin/connections", "Authorization", "Bearer " + signToken(t, testJWTSecret, "viewer", "read"), http.StatusForbidden},
+		{"token signed with another secret", "/api/admin/connections", "Authorization",
+			"Bearer " + signToken(t, "fedcba9876543210fedcba9876543210", "ops", config.RoleAdmin), http.StatusUnauthorized},
+		{"not a bearer token", "/api/admin/connections", "Authorization", "Basic b3BzOnNlY3JldA==", http.StatusUnauthorized},
+		{"market data stays open", "/api/pairs", "", "", http.StatusOK},
+		{"market data with a lesser key", "/api/pairs", apiKeyHeader, "read-key", http.StatusOK},
+	}
+	// Changing pairs needs the role too, the pair doesn't exist so an allowed call gets 404
+	writes := []struct {
+		name       string
+		key        string
+		wantStatus int
+	}{
+		{"remove pair without the role", "read-key", http.StatusForbidden},
+		{"remove pair as admin", "admin-key", http.StatusNotFound},
+	}
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/pairs/NOPEUSDT", nil)
+			req.Header.Set(apiKeyHeader, tt.key)
+			if rec := s.serve(req); rec.Code != tt.wantStatus {
+				t.Errorf("status = %d %q, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			if rec := s.serve(req); rec.Code != tt.wantStatus {
+				t.Errorf("status = %d %q, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+		})
+	}
+}
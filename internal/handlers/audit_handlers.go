@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/audit"
+	"github.com/sand/crypto-trading-app/backend/internal/auth"
+)
+
+// Page size of the audit log endpoint.
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// anonymousActor names the caller of an admin action when no authentication is configured.
+const anonymousActor = "anonymous"
+
+// recordAudit appends a successful admin action of the request's caller to the audit log.
+// A failure to persist it is logged, the action itself already happened.
+func (h *HTTPHandler) recordAudit(r *http.Request, action, target string, details map[string]any) {
+	actor := anonymousActor
+	if identity, ok := auth.FromContext(r.Context()); ok && identity.Subject != "" {
+		actor = identity.Subject
+	}
+	remoteAddr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remoteAddr = host
+	}
+
+	entry := audit.Entry{
+		Time:       time.Now().UnixMilli(),
+		Actor:      actor,
+		RemoteAddr: remoteAddr,
+		Action:     action,
+		Target:     target,
+		Details:    details,
+	}
+	if err := h.auditLog.Record(entry); err != nil {
+		h.logger.Error("Error recording audit entry", "action", action, "error", err)
+	}
+}
+
+// AuditHandler returns the recorded admin actions, newest first, a page at a time:
+// ?limit= entries (default 50, at most 500) after skipping ?offset= newer ones.
+func (h *HTTPHandler) AuditHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAuditLimit
+	value, err := int64QueryParam(r, "limit")
+	if err != nil || (value != nil && (*value <= 0 || *value > maxAuditLimit)) {
+		http.Error(w, "limit must be an integer between 1 and 500", http.StatusBadRequest)
+		return
+	}
+	if value != nil {
+		limit = int(*value)
+	}
+
+	offset := 0
+	value, err = int64QueryParam(r, "offset")
+	if err != nil || (value != nil && *value < 0) {
+		http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+	if value != nil {
+		offset = int(*value)
+	}
+
+	entries, total := h.auditLog.Page(offset, limit)
+	body := map[string]any{
+		"entries": entries,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
+		h.logger.Error("Error encoding audit log", "error", encodeErr)
+	}
+}
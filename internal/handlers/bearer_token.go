@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/auth"
+)
+
+// bearerTokenMiddleware authenticates requests carrying an Authorization: Bearer token and
+// puts the caller into the request context, see auth.FromContext. Requests without the
+// header stay anonymous; an invalid or expired token is refused with 401.
+func (h *HTTPHandler) bearerTokenMiddleware(next http.Handler) http.Handler {
+	if h.tokens == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Authorization must be a Bearer token", http.StatusUnauthorized)
+			return
+		}
+		claims, err := h.tokens.Verify(token, time.Now())
+		if err != nil {
+			h.logger.Warn("Rejected bearer token", "path", r.URL.Path, "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		identity := auth.Identity{Subject: claims.Subject, Roles: claims.Roles}
+		next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+	})
+}
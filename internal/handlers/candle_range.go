@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// candleRange is the time range of a candle query in milliseconds since the epoch, both ends inclusive.
+type candleRange struct {
+	start int64
+	end   int64
+}
+
+// parseCandleRange reads ?startTime= and ?endTime= and applies the maximum query span.
+// It returns nil when neither is given. A missing endTime means now, a missing startTime
+// the widest window allowed before endTime. Wider ranges are rejected or clamped to the
+// most recent window depending on mode.
+func parseCandleRange(r *http.Request, now time.Time, maxRange time.Duration, mode string) (*candleRange, error) {
+	start, err := int64QueryParam(r, "startTime")
+	if err != nil {
+		return nil, err
+	}
+	end, err := int64QueryParam(r, "endTime")
+	if err != nil {
+		return nil, err
+	}
+	if start == nil && end == nil {
+		return nil, nil
+	}
+
+	maxMs := maxRange.Milliseconds()
+	cr := &candleRange{end: now.UnixMilli()}
+	if end != nil {
+		cr.end = *end
+	}
+	cr.start = cr.end - maxMs
+	if start != nil {
+		cr.start = *start
+	}
+
+	if cr.start > cr.end {
+		return nil, fmt.Errorf("startTime must not be after endTime")
+	}
+	if cr.end-cr.start > maxMs {
+		if mode != config.QueryRangeClamp {
+			return nil, fmt.Errorf("time range exceeds the maximum of %s", maxRange)
+		}
+		cr.start = cr.end - maxMs
+	}
+	return cr, nil
+}
+
+// filter returns the candles starting within the range.
+func (cr *candleRange) filter(candles []models.CandleData) []models.CandleData {
+	filtered := candles[:0]
+	for _, candle := range candles {
+		if candle.Time >= cr.start && candle.Time <= cr.end {
+			filtered = append(filtered, candle)
+		}
+	}
+	return filtered
+}
+
+// int64QueryParam parses an optional integer query parameter, absent means nil.
+func int64QueryParam(r *http.Request, name string) (*int64, error) {
+	if !r.URL.Query().Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be milliseconds since the epoch", name)
+	}
+	return &value, nil
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestParseCandleRange(t *testing.T) {
+	now := time.UnixMilli(10_000_000)
+	maxRange := time.Hour // 3 600 000ms
+
+	tests := []struct {
+		name    string
+		query   string
+		mode    string
+		want    *candleRange
+		wantErr bool
+	}{
+		{"no range", "", config.QueryRangeReject, nil, false},
+		{"both ends", "?startTime=1000&endTime=2000", config.QueryRangeReject, &candleRange{1000, 2000}, false},
+		{"start only ends now", "?startTime=9000000", config.QueryRangeReject, &candleRange{9_000_000, 10_000_000}, false},
+		{"end only takes the widest window", "?endTime=5000000", config.QueryRangeReject, &candleRange{1_400_000, 5_000_000}, false},
+		{"exactly the maximum", "?startTime=0&endTime=3600000", config.QueryRangeReject, &candleRange{0, 3_600_000}, false},
+		{"too wide rejected", "?startTime=0&endTime=3600001", config.QueryRangeReject, nil, true},
+		{"too wide clamped", "?startTime=0&endTime=5000000", config.QueryRangeClamp, &candleRange{1_400_000, 5_000_000}, false},
+		{"inverted", "?startTime=2000&endTime=1000", config.QueryRangeClamp, nil, true},
+		{"not a number", "?startTime=yesterday", config.QueryRangeReject, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/candles/BTCUSDT"+tt.query, nil)
+			got, err := parseCandleRange(r, now, maxRange, tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCandleRange: %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandleRangeFilter(t *testing.T) {
+	candles := []models.CandleData{{Time: 1000}, {Time: 2000}, {Time: 3000}, {Time: 4000}}
+
+	got := (&candleRange{start: 2000, end: 3000}).filter(candles)
+	if len(got) != 2 || got[0].Time != 2000 || got[1].Time != 3000 {
+		t.Errorf("got %+v, want the candles at 2000 and 3000", got)
+	}
+}
+
+func TestCandlesOutsideMaxRange(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.MaxCandleQueryRange = time.Hour })
+	s.addPair(t, "TESTUSDT", 100)
+
+	rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/TESTUSDT?startTime=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetCandlesHeikinAshi(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.addPair(t, "TESTUSDT", 100)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"regular", "", http.StatusOK},
+		{"heikin-ashi", "?ha=true", http.StatusOK},
+		{"heikin-ashi over a range", "?ha=1&startTime=" + strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10), http.StatusOK},
+		{"invalid flag", "?ha=maybe", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := srv.serve(httptest.NewRequest(http.MethodGet, "/api/candles/TESTUSDT"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %q, want %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var candles []models.CandleData
+			if err := json.Unmarshal(rec.Body.Bytes(), &candles); err != nil {
+				t.Fatalf("decoding candles: %v", err)
+			}
+			for _, c := range candles {
+				if c.High < max(c.Open, c.Close) || c.Low > min(c.Open, c.Close) {
+					t.Fatalf("candle %+v has its body outside its range", c)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCandlesHeikinAshiRangeCutAfterTransform(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.addPair(t, "TESTUSDT", 100)
+
+	get := func(query string) []models.CandleData {
+		t.Helper()
+		rec := srv.serve(httptest.NewRequest(http.MethodGet, "/api/candles/TESTUSDT"+query, nil))
+		var candles []models.CandleData
+		if err := json.Unmarshal(rec.Body.Bytes(), &candles); err != nil {
+			t.Fatalf("decoding %s: %v", rec.Body.String(), err)
+		}
+		return candles
+	}
+
+	// Ticks only move the current candle, the closed ones compared here stay put
+	all := get("?ha=true")
+	start := all[len(all)-3].Time
+	ranged := get("?ha=true&startTime=" + strconv.FormatInt(start, 10))
+	if len(ranged) == 0 || ranged[0] != all[len(all)-3] {
+		t.Fatalf("first candle of the range %+v, want %+v from the full series", ranged, all[len(all)-3])
+	}
+}
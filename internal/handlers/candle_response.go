@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// Candle directions derived from close vs open.
+const (
+	directionUp   = "up"
+	directionDown = "down"
+	directionFlat = "flat"
+)
+
+// candleResponse wraps a stored candle with fields derived for output only,
+// so the stored model stays free of presentation concerns.
+type candleResponse struct {
+	models.FormattedCandle
+
+	Direction string `json:"direction,omitempty"` // Set when ?withDirection=true.
+}
+
+// candleDirection classifies a candle as up, down or flat.
+func candleDirection(candle models.CandleData) string {
+	switch {
+	case candle.Close > candle.Open:
+		return directionUp
+	case candle.Close < candle.Open:
+		return directionDown
+	default:
+		return directionFlat
+	}
+}
+
+// formatCandles wraps candles with their time in the given format and, if asked, their direction.
+func formatCandles(candles []models.CandleData, timeFormat string, withDirection bool) []candleResponse {
+	result := make([]candleResponse, len(candles))
+	for i, candle := range candles {
+		result[i] = candleResponse{FormattedCandle: candle.WithTimeFormat(timeFormat)}
+		if withDirection {
+			result[i].Direction = candleDirection(candle)
+		}
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestCandleDirection(t *testing.T) {
+	tests := []struct {
+		name        string
+		open, close float64
+		want        string
+	}{
+		{"up", 100, 101, directionUp},
+		{"down", 100, 99, directionDown},
+		{"flat", 100, 100, directionFlat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := candleDirection(models.CandleData{Open: tt.open, Close: tt.close}); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandlesWithDirection(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "TESTUSDT", 100)
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"default", "", false},
+		{"requested", "?withDirection=true", true},
+		{"declined", "?withDirection=false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/TESTUSDT"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+
+			var candles []map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &candles); err != nil {
+				t.Fatalf("decoding candles: %v", err)
+			}
+			if len(candles) == 0 {
+				t.Fatal("no candles")
+			}
+			for _, candle := range candles {
+				direction, ok := candle["direction"]
+				if ok != tt.want {
+					t.Fatalf("candle %v has direction %v, want %v", candle["time"], ok, tt.want)
+				}
+				if ok && direction != candleDirection(models.CandleData{
+					Open: candle["open"].(float64), Close: candle["close"].(float64),
+				}) {
+					t.Fatalf("candle %v has direction %v", candle, direction)
+				}
+			}
+		})
+	}
+}
+
+func TestCandlesThroughAlias(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.SymbolAliases = map[string]string{"XBTUSDT": "BTCUSDT"}
+	})
+	s.addPair(t, "BTCUSDT", 50000)
+
+	// getCandles returns the closed candles of path, the one in progress may tick between requests.
+	getCandles := func(path string) []map[string]any {
+		t.Helper()
+		rec := s.serve(httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		var candles []map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &candles); err != nil {
+			t.Fatalf("%s: decoding candles: %v", path, err)
+		}
+		if len(candles) == 0 {
+			t.Fatalf("%s: no candles", path)
+		}
+		return candles[:len(candles)-1]
+	}
+
+	// Candles are matched by time, a rollover between the requests may shift the history
+	canonical := make(map[any]map[string]any)
+	for _, candle := range getCandles("/api/candles/BTCUSDT") {
+		canonical[candle["time"]] = candle
+	}
+	matched := 0
+	for _, candle := range getCandles("/api/candles/XBTUSDT") {
+		if want, ok := canonical[candle["time"]]; ok {
+			matched++
+			if !maps.Equal(candle, want) {
+				t.Fatalf("candle through the alias is %v, want %v", candle, want)
+			}
+		}
+	}
+	if matched < len(canonical)-1 {
+		t.Errorf("only %d of %d candles of BTCUSDT returned through the alias", matched, len(canonical))
+	}
+
+	if rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/XETUSDT", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown alias: status %d, want 404", rec.Code)
+	}
+}
+
+func TestCandleTimeFormats(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+
+	tests := []struct {
+		format string
+		check  func(time any) bool // Whether the rendered time is in the format.
+	}{
+		{models.TimeFormatMillis, func(v any) bool {
+			ms, ok := v.(float64)
+			return ok && ms == math.Trunc(ms) && ms > 1e12
+		}},
+		{models.TimeFormatSeconds, func(v any) bool {
+			sec, ok := v.(float64)
+			return ok && sec == math.Trunc(sec) && sec > 1e9 && sec < 1e11
+		}},
+		{models.TimeFormatISO, func(v any) bool {
+			iso, ok := v.(string)
+			_, err := time.Parse(time.RFC3339, iso)
+			return ok && err == nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" REST", func(t *testing.T) {
+			rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/BTCUSDT?timeFormat="+tt.format, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			var candles []map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &candles); err != nil {
+				t.Fatalf("decoding candles: %v", err)
+			}
+			if len(candles) == 0 {
+				t.Fatal("no candles")
+			}
+			for _, candle := range candles {
+				if !tt.check(candle["time"]) {
+					t.Fatalf("candle time %v (%T) is not in %s format", candle["time"], candle["time"], tt.format)
+				}
+			}
+		})
+
+		t.Run(tt.format+" WebSocket", func(t *testing.T) {
+			conn := s.dial(t, "/ws/BTCUSDT")
+			send(t, conn, `{"action":"subscribe","symbol":"ETHUSDT","timeFormat":"`+tt.format+`"}`)
+			readFrame(t, conn, messageTypeSubscribed)
+
+			// The format applies to the whole connection, an update of the new pair is sure to follow it
+			if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			var update map[string]any
+			for update == nil || update["type"] != nil || update["symbol"] != "ETHUSDT" {
+				update = nil
+				if err := conn.ReadJSON(&update); err != nil {
+					t.Fatalf("waiting for an ETHUSDT update: %v", err)
+				}
+			}
+			candle, _ := update["lastCandle"].(map[string]any)
+			if !tt.check(candle["time"]) {
+				t.Errorf("lastCandle time %v (%T) is not in %s format", candle["time"], candle["time"], tt.format)
+			}
+		})
+	}
+
+	if rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/BTCUSDT?timeFormat=rfc822", nil)); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import "time"
+
+// controlLimiter counts the control messages of one connection per one-second window. It is
+// only used by the connection's read loop, so it needs no locking.
+type controlLimiter struct {
+	limit  int       // Messages allowed per window, 0 for no limit.
+	window time.Time // Start of the current window.
+	count  int       // Messages seen in the current window.
+}
+
+// allow records a message received at now and reports whether it is within the limit.
+func (l *controlLimiter) allow(now time.Time) bool {
+	if l.limit == 0 {
+		return true
+	}
+	if now.Sub(l.window) >= time.Second {
+		l.window = now
+		l.count = 0
+	}
+	l.count++
+	return l.count <= l.limit
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	gorilla "github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/audit"
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/logstream"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+// testServer is the HTTP and WebSocket API wired like main does, without starting pairs.
+type testServer struct {
+	handler     *HTTPHandler
+	router      *mux.Router
+	dataService *services.DataService
+	manager     *websocket.Manager
+	server      *httptest.Server // Serves the router for WebSocket clients.
+}
+
+// adminRequest returns a request to target carrying testAdminKey.
+func adminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set(apiKeyHeader, testAdminKey)
+	return req
+}
+
+// newTestServer builds the API from the default configuration, changed by configure if
+// given. Pairs added by the test are removed when it ends.
+func newTestServer(t *testing.T, configure func(*config.Config)) *testServer {
+	t.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dataService, err := services.NewDataService(logger, cfg)
+	if err != nil {
+		t.Fatalf("creating data service: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, pair := range dataService.Pairs() {
+			_ = dataService.RemoveTradingPair(context.Background(), pair.Symbol)
+		}
+	})
+	auditLog, err := audit.Open("")
+	if err != nil {
+		t.Fatalf("opening audit log: %v", err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+
+	appMetrics := metrics.New()
+	manager := websocket.NewWebSocketManager(logger, cfg.WebSocket, cfg.JSONNaming, cfg.JSONNumbers, appMetrics)
+	handler := NewHTTPHandler(logger, dataService, manager, appMetrics, logstream.NewHub(), auditLog, cfg)
+
+	router := mux.NewRouter()
+	NewWebSocketHandler(logger, dataService, manager, cfg).RegisterRoutes(router)
+	handler.RegisterRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return &testServer{handler: handler, router: router, dataService: dataService, manager: manager, server: server}
+}
+
+// addPair adds a simulated pair at the given price.
+func (s *testServer) addPair(t *testing.T, symbol string, price float64) {
+	t.Helper()
+
+	if _, _, err := s.dataService.AddTradingPair(context.Background(), symbol, &price, nil, false); err != nil {
+		t.Fatalf("adding pair %s: %v", symbol, err)
+	}
+}
+
+// serve runs the request through the router and returns the recorded response.
+func (s *testServer) serve(r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, r)
+	return rec
+}
+
+// dial opens a WebSocket connection to path, e.g. /ws/BTCUSDT.
+func (s *testServer) dial(t *testing.T, path string) *gorilla.Conn {
+	t.Helper()
+
+	conn, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+path, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", path, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// send writes a client message.
+func send(t *testing.T, conn *gorilla.Conn, message string) {
+	t.Helper()
+
+	if err := conn.WriteMessage(gorilla.TextMessage, []byte(message)); err != nil {
+		t.Fatalf("sending %s: %v", message, err)
+	}
+}
+
+// readFrame reads frames until one of the given type arrives, skipping price updates and
+// other frames, and returns it decoded.
+func readFrame(t *testing.T, conn *gorilla.Conn, frameType string) map[string]any {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("setting read deadline: %v", err)
+	}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for a %s frame: %v", frameType, err)
+		}
+		var frame map[string]any
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("decoding frame %s: %v", data, err)
+		}
+		if frame["type"] == frameType {
+			return frame
+		}
+	}
+}
+
+// ptr returns a pointer to v, for optional request fields.
+func ptr[T any](v T) *T {
+	return &v
+}
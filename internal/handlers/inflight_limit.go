@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"sync"
+)
+
+// inflightLimiter counts the API requests in progress per client IP.
+type inflightLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	limit  int // Concurrent requests allowed per IP, 0 for no limit.
+}
+
+func newInflightLimiter(limit int) *inflightLimiter {
+	return &inflightLimiter{counts: make(map[string]int), limit: limit}
+}
+
+// acquire takes a slot for ip, it reports false when the IP already uses all of them.
+func (l *inflightLimiter) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.counts[ip] >= l.limit {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+// release frees a slot taken by acquire. IPs without requests are forgotten.
+func (l *inflightLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.counts[ip]--; l.counts[ip] <= 0 {
+		delete(l.counts, ip)
+	}
+}
+
+// inflightLimitMiddleware rejects a request with 429 while its client IP already has the
+// configured number of API requests in progress, so one client can't tie up the server with
+// expensive queries. Forwarding headers are not trusted, the IP is the peer address.
+func (h *HTTPHandler) inflightLimitMiddleware(next http.Handler) http.Handler {
+	if h.inflight.limit == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+
+		if !h.inflight.acquire(ip) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many concurrent requests", http.StatusTooManyRequests)
+			return
+		}
+		defer h.inflight.release(ip)
+
+		next.ServeHTTP(w, r)
+	})
+}
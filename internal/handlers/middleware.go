@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// requestTimeout bounds how long a single API request may spend in the services.
+const requestTimeout = 10 * time.Second
+
+// timeoutMiddleware attaches a deadline to the request context so that service calls
+// are abandoned once the client is gone or the request takes too long.
+func timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// instrumentMiddleware logs each API request and records its latency and status.
+func (h *HTTPHandler) instrumentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		duration := time.Since(start)
+		route := routeTemplate(r)
+		h.metrics.ObserveRequest(route, r.Method, rec.status, duration)
+		h.logger.Debug("Handled request", "method", r.Method, "route", route,
+			"status", rec.status, "duration", duration)
+	})
+}
+
+// namingMiddleware rewrites the keys and numbers of JSON responses to the configured naming
+// style and number format. Handlers keep encoding their usual camelCase structures; with the
+// defaults responses pass through untouched.
+func (h *HTTPHandler) namingMiddleware(next http.Handler) http.Handler {
+	if h.naming != naming.StyleSnake && h.numbers != naming.NumbersPlain {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		body := buf.body.Bytes()
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			converted, err := naming.TransformJSON(body, h.naming)
+			if err != nil {
+				h.logger.Error("Error converting response keys", "error", err)
+			} else {
+				body = append(converted, '\n')
+			}
+			if h.numbers == naming.NumbersPlain {
+				body = naming.PlainNumbers(body)
+			}
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		if _, err := w.Write(body); err != nil {
+			h.logger.Debug("Error writing response", "error", err)
+		}
+	})
+}
+
+// routeTemplate returns the mux template that matched the request (e.g. /api/candles/{symbol}).
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return "unmatched"
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// bufferedResponse holds a handler's response so it can be rewritten before sending.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
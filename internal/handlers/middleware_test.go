@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+func TestTimeoutMiddlewareSetsDeadline(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	handler := timeoutMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	}))
+
+	start := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/pairs", nil))
+
+	if !ok {
+		t.Fatal("request context has no deadline")
+	}
+	if limit := start.Add(requestTimeout); deadline.After(limit.Add(time.Second)) || deadline.Before(start) {
+		t.Errorf("deadline %v, want about %v", deadline, limit)
+	}
+}
+
+func TestExpiredRequestContext(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "TESTUSDT", 100)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "/api/candles/TESTUSDT", nil).WithContext(ctx)
+
+	rec := s.serve(r)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestInstrumentMiddlewareRecordsRouteTemplate(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/NOPEUSDT", nil))
+	s.serve(httptest.NewRequest(http.MethodGet, "/api/candles/OTHERUSDT", nil))
+
+	body := s.serve(httptest.NewRequest(http.MethodGet, "/metrics", nil)).Body.String()
+	want := `http_requests_total{code_class="4xx",method="GET",route="/api/candles/{symbol}"} 2`
+	if !strings.Contains(body, want) {
+		t.Errorf("metrics lack %s", want)
+	}
+	if strings.Contains(body, "NOPEUSDT") {
+		t.Error("metrics are labelled with the concrete path")
+	}
+}
+
+func TestNamingMiddlewarePlainNumbers(t *testing.T) {
+	tests := []struct {
+		name         string
+		numbers      string
+		wantExponent bool
+	}{
+		{"default", naming.NumbersDefault, true},
+		{"plain", naming.NumbersPlain, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(cfg *config.Config) { cfg.JSONNumbers = tt.numbers })
+			s.addPair(t, "TINYUSDT", 1e-7)
+
+			rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/pairs", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			if got := strings.Contains(rec.Body.String(), "e-"); got != tt.wantExponent {
+				t.Errorf("exponent notation in %s: %v, want %v", rec.Body, got, tt.wantExponent)
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Errorf("response %s is not valid JSON", rec.Body)
+			}
+		})
+	}
+}
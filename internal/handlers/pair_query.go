@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Sort orders accepted by GET /api/pairs.
+const (
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// pairSummary is one entry of the trading pairs list.
+type pairSummary struct {
+	Symbol      string  `json:"symbol"`
+	LastPrice   float64 `json:"lastPrice"`
+	MarkPrice   float64 `json:"markPrice"`
+	PriceChange float64 `json:"priceChange"`
+	Momentum    string  `json:"momentum"`   // Derived from PriceChange, see services.Momentum.
+	LastUpdate  int64   `json:"lastUpdate"` // Milliseconds since the epoch.
+	AgeMs       int64   `json:"ageMs"`      // Milliseconds since LastUpdate.
+	Stale       bool    `json:"stale"`      // No update within the stale pair threshold, the price is frozen.
+}
+
+// pairSortKeys lists the fields pairs may be sorted by.
+var pairSortKeys = map[string]func(a, b *pairSummary) bool{
+	"symbol":      func(a, b *pairSummary) bool { return a.Symbol < b.Symbol },
+	"lastPrice":   func(a, b *pairSummary) bool { return a.LastPrice < b.LastPrice },
+	"priceChange": func(a, b *pairSummary) bool { return a.PriceChange < b.PriceChange },
+}
+
+// pairQuery holds the sort and filter options of GET /api/pairs.
+type pairQuery struct {
+	sortKey   string
+	desc      bool
+	minChange *float64
+	maxChange *float64
+}
+
+// parsePairQuery reads and validates the sort and filter query parameters.
+func parsePairQuery(r *http.Request) (*pairQuery, error) {
+	query := r.URL.Query()
+	q := &pairQuery{sortKey: "symbol"}
+
+	if key := query.Get("sort"); key != "" {
+		if _, ok := pairSortKeys[key]; !ok {
+			return nil, fmt.Errorf("sort must be one of symbol, lastPrice, priceChange, got %q", key)
+		}
+		q.sortKey = key
+	}
+
+	switch order := query.Get("order"); order {
+	case "", orderAsc:
+	case orderDesc:
+		q.desc = true
+	default:
+		return nil, fmt.Errorf("order must be %s or %s", orderAsc, orderDesc)
+	}
+
+	var err error
+	if q.minChange, err = floatQueryParam(r, "minChange"); err != nil {
+		return nil, err
+	}
+	if q.maxChange, err = floatQueryParam(r, "maxChange"); err != nil {
+		return nil, err
+	}
+	if q.minChange != nil && q.maxChange != nil && *q.minChange > *q.maxChange {
+		return nil, errors.New("minChange must not exceed maxChange")
+	}
+
+	return q, nil
+}
+
+// apply filters pairs by price change and sorts them.
+func (q *pairQuery) apply(pairs []pairSummary) []pairSummary {
+	filtered := pairs[:0]
+	for _, pair := range pairs {
+		if q.minChange != nil && pair.PriceChange < *q.minChange {
+			continue
+		}
+		if q.maxChange != nil && pair.PriceChange > *q.maxChange {
+			continue
+		}
+		filtered = append(filtered, pair)
+	}
+
+	less := pairSortKeys[q.sortKey]
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if q.desc {
+			return less(&filtered[j], &filtered[i])
+		}
+		return less(&filtered[i], &filtered[j])
+	})
+	return filtered
+}
+
+// floatQueryParam parses an optional float query parameter, absent means nil.
+func floatQueryParam(r *http.Request, name string) (*float64, error) {
+	if !r.URL.Query().Has(name) {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(r.URL.Query().Get(name), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &value, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestPairQuery(t *testing.T) {
+	pairs := []pairSummary{
+		{Symbol: "SOLUSDT", LastPrice: 150, PriceChange: -3},
+		{Symbol: "BTCUSDT", LastPrice: 50000, PriceChange: 1},
+		{Symbol: "ETHUSDT", LastPrice: 3000, PriceChange: 5},
+		{Symbol: "XRPUSDT", LastPrice: 0.5, PriceChange: 0},
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"default by symbol", "", []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"}, false},
+		{"top movers", "?sort=priceChange&order=desc", []string{"ETHUSDT", "BTCUSDT", "XRPUSDT", "SOLUSDT"}, false},
+		{"by price", "?sort=lastPrice&order=asc", []string{"XRPUSDT", "SOLUSDT", "ETHUSDT", "BTCUSDT"}, false},
+		{"gainers", "?minChange=0", []string{"BTCUSDT", "ETHUSDT", "XRPUSDT"}, false},
+		{"range", "?minChange=-5&maxChange=1&sort=priceChange", []string{"SOLUSDT", "XRPUSDT", "BTCUSDT"}, false},
+		{"empty range", "?minChange=10", []string{}, false},
+		{"unknown sort key", "?sort=volume", nil, true},
+		{"unknown order", "?order=random", nil, true},
+		{"change not a number", "?minChange=high", nil, true},
+		{"inverted range", "?minChange=2&maxChange=1", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parsePairQuery(httptest.NewRequest(http.MethodGet, "/api/pairs"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePairQuery: %v", err)
+			}
+
+			got := []string{}
+			for _, pair := range q.apply(slices.Clone(pairs)) {
+				got = append(got, pair.Symbol)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTradingPairsRejectsInvalidQuery(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	rec := s.serve(httptest.NewRequest(http.MethodGet, "/api/pairs?sort=volume", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
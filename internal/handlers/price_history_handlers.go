@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+)
+
+// GetPriceHistoryHandler returns a pair's price as plain time and price points, for clients
+// that draw a line and don't need OHLCV. It takes the ?startTime=/?endTime= range of the
+// candle endpoint and an optional ?limit= on the number of points.
+func (h *HTTPHandler) GetPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	queryRange, err := parseCandleRange(r, h.dataService.Now(), h.maxQueryRange, h.queryRangeMode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := int64QueryParam(r, "limit")
+	if err != nil || (limit != nil && *limit <= 0) {
+		http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	// Without a range the whole history is returned
+	start, end := int64(math.MinInt64), int64(math.MaxInt64)
+	if queryRange != nil {
+		start, end = queryRange.start, queryRange.end
+	}
+	maxPoints := 0
+	if limit != nil {
+		maxPoints = int(min(*limit, math.MaxInt32))
+	}
+
+	history, err := h.dataService.PriceHistory(r.Context(), symbol, start, end, maxPoints)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Price history request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(history); encodeErr != nil {
+		h.logger.Error("Error encoding price history", "error", encodeErr)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  time.Duration
+		wantStatus int
+		wantStale  []string
+	}{
+		{"pairs updating", time.Hour, http.StatusOK, nil},
+		{"pairs stalled", time.Nanosecond, http.StatusServiceUnavailable, []string{"TESTUSDT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(cfg *config.Config) { cfg.StalePairThreshold = tt.threshold })
+			s.addPair(t, "TESTUSDT", 100)
+
+			rec := s.serve(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Status     string   `json:"status"`
+				StalePairs []string `json:"stalePairs"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding readiness: %v", err)
+			}
+			if !slices.Equal(body.StalePairs, tt.wantStale) {
+				t.Errorf("stale pairs %v, want %v", body.StalePairs, tt.wantStale)
+			}
+		})
+	}
+}
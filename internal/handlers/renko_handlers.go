@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+)
+
+// Bounds of the ATR period of a Renko query.
+const (
+	minRenkoATRPeriod = 1
+	maxRenkoATRPeriod = 100
+)
+
+// GetRenkoHandler returns the Renko bricks of a pair, with a fixed ?boxSize= or one sized by
+// the average true range over ?atr= candles.
+func (h *HTTPHandler) GetRenkoHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	boxSize, err := floatQueryParam(r, "boxSize")
+	if err != nil || (boxSize != nil && *boxSize <= 0) {
+		http.Error(w, "boxSize must be a positive number", http.StatusBadRequest)
+		return
+	}
+	atr, err := int64QueryParam(r, "atr")
+	if err != nil || (atr != nil && (*atr < minRenkoATRPeriod || *atr > maxRenkoATRPeriod)) {
+		http.Error(w, fmt.Sprintf("atr must be an integer between %d and %d", minRenkoATRPeriod, maxRenkoATRPeriod),
+			http.StatusBadRequest)
+		return
+	}
+	if (boxSize == nil) == (atr == nil) {
+		http.Error(w, "exactly one of boxSize and atr is required", http.StatusBadRequest)
+		return
+	}
+
+	interval := h.dataService.BaseInterval()
+	if value := r.URL.Query().Get("interval"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "interval must be a duration such as 15m", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var size float64
+	var period int
+	if boxSize != nil {
+		size = *boxSize
+	} else {
+		period = int(*atr)
+	}
+
+	renko, err := h.dataService.Renko(r.Context(), symbol, interval, size, period)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrUnsupportedInterval):
+			http.Error(w, "Unsupported interval, see /api/meta", http.StatusBadRequest)
+		case errors.Is(err, services.ErrInsufficientHistory):
+			http.Error(w, "Not enough candles for the atr period", http.StatusUnprocessableEntity)
+		case errors.Is(err, services.ErrTooManyBricks):
+			http.Error(w, "boxSize is too small for the price range", http.StatusUnprocessableEntity)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Renko request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(renko); encodeErr != nil {
+		h.logger.Error("Error encoding renko bricks", "error", encodeErr)
+	}
+}
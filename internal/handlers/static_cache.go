@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// fingerprintPattern matches build output whose name carries a content hash, such as
+// static/js/main.3f2a9c1b.js or static/media/logo.6ce24c58.svg. Such a file never changes
+// under the same name, so browsers may keep it for as long as they like.
+var fingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{8,}(\.chunk)?\.[a-z0-9]+$`)
+
+// staticCacheHandler sets caching headers on static files: fingerprinted assets are cached
+// for maxAge, index.html is revalidated on every load so a deploy is picked up right away.
+// Other files keep the file server's defaults. A zero maxAge leaves all headers untouched.
+func staticCacheHandler(next http.Handler, maxAge time.Duration) http.Handler {
+	if maxAge <= 0 {
+		return next
+	}
+
+	longCache := "public, max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10) + ", immutable"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &cacheHeaderWriter{ResponseWriter: w}
+		name := path.Base(r.URL.Path)
+		switch {
+		case r.URL.Path == "" || name == "index.html":
+			cw.cacheControl, cw.expires = "no-cache", "0"
+		case fingerprintPattern.MatchString(name):
+			cw.cacheControl = longCache
+			cw.expires = time.Now().Add(maxAge).UTC().Format(http.TimeFormat)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// cacheHeaderWriter adds caching headers to successful responses only, so that a missing
+// asset's 404 isn't cached for as long as the asset would have been.
+type cacheHeaderWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	expires      string
+	wroteHeader  bool
+}
+
+func (cw *cacheHeaderWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if status < http.StatusBadRequest {
+			cw.Header().Set("Cache-Control", cw.cacheControl)
+			cw.Header().Set("Expires", cw.expires)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheHeaderWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *cacheHeaderWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStaticCacheHandler(t *testing.T) {
+	files := http.FileServer(http.FS(fstest.MapFS{
+		"index.html":                      {Data: []byte("<html></html>")},
+		"favicon.ico":                     {Data: []byte("icon")},
+		"static/js/main.3f2a9c1b.js":      {Data: []byte("js")},
+		"static/js/453.8f1c2d3e.chunk.js": {Data: []byte("js")},
+	}))
+
+	tests := []struct {
+		name             string
+		maxAge           time.Duration
+		path             string
+		wantStatus       int
+		wantCacheControl string
+		wantExpires      bool
+	}{
+		{"root", time.Hour, "/", http.StatusOK, "no-cache", true},
+		{"fingerprinted asset", time.Hour, "/static/js/main.3f2a9c1b.js", http.StatusOK, "public, max-age=3600, immutable", true},
+		{"fingerprinted chunk", time.Hour, "/static/js/453.8f1c2d3e.chunk.js", http.StatusOK, "public, max-age=3600, immutable", true},
+		{"plain file", time.Hour, "/favicon.ico", http.StatusOK, "", false},
+		{"missing asset", time.Hour, "/static/js/main.deadbeef.js", http.StatusNotFound, "", false},
+		{"disabled", 0, "/", http.StatusOK, "", false},
+		{"disabled asset", 0, "/static/js/main.3f2a9c1b.js", http.StatusOK, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.StripPrefix("/", staticCacheHandler(files, tt.maxAge))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+			}
+			if got := rec.Header().Get("Expires"); (got != "") != tt.wantExpires {
+				t.Errorf("Expires = %q, want it set: %v", got, tt.wantExpires)
+			}
+		})
+	}
+}
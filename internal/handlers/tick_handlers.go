@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+)
+
+// defaultTickLimit is the number of ticks returned without ?limit=.
+const defaultTickLimit = 200
+
+// GetTicksHandler returns the most recent raw price ticks of a pair, oldest first. ?limit=
+// larger than the retained ticks returns all of them.
+func (h *HTTPHandler) GetTicksHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+
+	limit := defaultTickLimit
+	value, err := int64QueryParam(r, "limit")
+	if err != nil || (value != nil && *value <= 0) {
+		http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	if value != nil {
+		limit = int(min(*value, math.MaxInt32))
+	}
+
+	ticks, err := h.dataService.GetTicks(r.Context(), symbol, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingPairNotFound):
+			http.Error(w, "Trading pair not found", http.StatusNotFound)
+		case errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+		case errors.Is(err, context.Canceled):
+			h.logger.Debug("Tick request cancelled", "symbol", symbol)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(ticks); encodeErr != nil {
+		h.logger.Error("Error encoding ticks", "error", encodeErr)
+	}
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/sand/crypto-trading-app/backend/internal/config"
 	"github.com/sand/crypto-trading-app/backend/internal/services"
 	"github.com/sand/crypto-trading-app/backend/internal/websocket"
 )
@@ -14,17 +20,27 @@ type WebSocketHandler struct {
 	logger           *slog.Logger
 	dataService      *services.DataService
 	websocketManager *websocket.Manager
+	welcome          bool // Whether connections open with a welcome frame.
+	maxControlRate   int  // Control messages per second per connection, 0 for no limit.
+	maxSubscriptions int  // Subscriptions per connection across channels, 0 for no limit.
 }
 
+// errSubscriptionCap rejects a subscribe of a connection already holding maxSubscriptions.
+var errSubscriptionCap = errors.New("subscription cap reached")
+
 func NewWebSocketHandler(
 	logger *slog.Logger,
 	dataService *services.DataService,
 	websocketManager *websocket.Manager,
+	cfg *config.Config,
 ) *WebSocketHandler {
 	return &WebSocketHandler{
 		logger:           logger,
 		dataService:      dataService,
 		websocketManager: websocketManager,
+		welcome:          cfg.WebSocket.Welcome,
+		maxControlRate:   cfg.WebSocket.MaxControlRate,
+		maxSubscriptions: cfg.WebSocket.MaxSubscriptions,
 	}
 }
 
@@ -36,39 +52,280 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	symbol := vars["symbol"]
 
+	// Clients are sent elsewhere while the server drains
+	if h.websocketManager.Draining() {
+		retryAfter := h.websocketManager.RetryAfter() / time.Second
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter)))
+		http.Error(w, "Server is draining", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Check if the trading pair exists
-	_, exists := h.dataService.TradingPairs[symbol]
-	if !exists {
+	if !h.dataService.HasPair(symbol) {
 		http.Error(w, "Trading pair not found", http.StatusNotFound)
 		return
 	}
 
-	conn, err := h.websocketManager.Upgrade(w, r)
+	// The connection may pick its own backpressure policy instead of the server default
+	policy := r.URL.Query().Get("backpressure")
+	if policy != "" && !config.IsBackpressurePolicy(policy) {
+		http.Error(w, "Unknown backpressure policy", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.websocketManager.Upgrade(w, r)
 	if err != nil {
 		h.logger.Error("Error upgrading connection", "error", err)
 		return
 	}
+	if policy != "" {
+		sub.SetBackpressure(policy)
+	}
+
+	h.logger.Info("New WebSocket connection", "conn", sub.ID(), "symbol", symbol)
 
-	h.logger.Info("New WebSocket connection", "symbol", symbol)
+	// The welcome frame is queued before the first update can be
+	if h.welcome {
+		h.sendWelcome(sub)
+	}
 
 	// Add subscriber
-	err = h.dataService.AddSubscriber(symbol, conn)
+	err = h.dataService.AddSubscriber(r.Context(), symbol, sub)
 	if err != nil {
-		h.logger.Error("Error adding subscriber", "error", err)
-		conn.Close()
+		h.logger.Error("Error adding subscriber", "conn", sub.ID(), "error", err)
+		sub.Close()
 		return
 	}
 
-	// Keep connection open and handle disconnection
+	// Keep connection open, apply control messages and handle disconnection
+	limiter := controlLimiter{limit: h.maxControlRate}
 	for {
-		_, _, readErr := conn.ReadMessage()
+		_, message, readErr := sub.Conn().ReadMessage()
 		if readErr != nil {
-			h.logger.Error("WebSocket connection closed", "symbol", symbol, "error", readErr)
-			removeErr := h.dataService.RemoveSubscriber(symbol, conn)
-			if removeErr != nil {
-				h.logger.Error("Error removing subscriber", "error", removeErr)
-			}
+			h.logger.Error("WebSocket connection closed", "conn", sub.ID(), "symbol", symbol, "error", readErr)
+			h.dataService.RemoveSubscriberFromAll(context.WithoutCancel(r.Context()), sub)
+			sub.Close()
 			break
 		}
+
+		sub.Touch()
+		if !limiter.allow(time.Now()) {
+			h.reject(sub, peekRequestID(message), &protocolError{
+				Code:    codeRateLimited,
+				Message: fmt.Sprintf("more than %d control messages per second", h.maxControlRate),
+			})
+			continue
+		}
+		h.handleMessage(r.Context(), sub, message)
+	}
+}
+
+// sendWelcome queues the first frame of the connection with its ID and the server's capabilities.
+func (h *WebSocketHandler) sendWelcome(sub *websocket.Subscriber) {
+	protocol := sub.Conn().Subprotocol()
+	if protocol == "" {
+		protocol = websocket.Protocol
+	}
+
+	welcome := welcomeMessage{
+		Type:              messageTypeWelcome,
+		ConnectionID:      sub.ID(),
+		ServerTime:        time.Now().UnixMilli(),
+		Protocol:          protocol,
+		SupportedChannels: supportedChannels,
+	}
+	if !sub.SendControl(welcome) {
+		h.logger.Warn("Welcome frame not delivered, send queue full", "conn", sub.ID())
+	}
+}
+
+// handleMessage applies a control message sent by the client. Rejected messages are
+// answered with an error frame and never close the connection.
+func (h *WebSocketHandler) handleMessage(ctx context.Context, sub *websocket.Subscriber, message []byte) {
+	msg, protoErr := parseControlMessage(message)
+	if protoErr == nil {
+		protoErr = h.applyControlMessage(ctx, sub, msg)
+	}
+	if protoErr == nil {
+		return
+	}
+
+	requestID := peekRequestID(message)
+	if msg != nil {
+		requestID = msg.RequestID
+	}
+	h.reject(sub, requestID, protoErr)
+}
+
+// reject answers a client message with an error frame, the connection stays open.
+func (h *WebSocketHandler) reject(sub *websocket.Subscriber, requestID string, protoErr *protocolError) {
+	h.logger.Warn("Rejected WebSocket message", "conn", sub.ID(), "code", protoErr.Code, "error", protoErr.Message)
+	if !sub.SendControl(protoErr.frame(requestID)) {
+		h.logger.Warn("Error frame not delivered, send queue full", "conn", sub.ID(), "code", protoErr.Code)
+	}
+}
+
+// applyControlMessage executes a validated control message.
+func (h *WebSocketHandler) applyControlMessage(
+	ctx context.Context,
+	sub *websocket.Subscriber,
+	msg *controlMessage,
+) *protocolError {
+	if msg.Symbols != nil {
+		h.applyBulk(ctx, sub, msg)
+		return nil
+	}
+
+	var err error
+	switch msg.Action {
+	case actionSubscribe:
+		err = h.changeSubscription(ctx, sub, msg, msg.Symbol)
+		if err == nil {
+			applySubscribeOptions(sub, msg)
+		}
+	case actionUnsubscribe:
+		err = h.changeSubscription(ctx, sub, msg, msg.Symbol)
+	case actionSetFields:
+		sub.SetFields(msg.Fields)
+		return nil
+	}
+
+	if protoErr := h.symbolError(sub, msg.Action, msg.Symbol, err); protoErr != nil {
+		return protoErr
+	}
+	h.acknowledge(sub, msg)
+	return nil
+}
+
+// acknowledge confirms an applied single-symbol (un)subscribe, so clients know the server
+// processed it.
+func (h *WebSocketHandler) acknowledge(sub *websocket.Subscriber, msg *controlMessage) {
+	ack := ackMessage{
+		Type:          messageTypeSubscribed,
+		Channel:       channelCandles,
+		Symbol:        msg.Symbol,
+		Subscriptions: len(sub.Symbols()) + len(sub.Klines()),
+		RequestID:     msg.RequestID,
+	}
+	if msg.Action == actionUnsubscribe {
+		ack.Type = messageTypeUnsubscribed
+	}
+	if msg.Channel == channelKline {
+		ack.Channel = channelKline
+		ack.Interval = msg.Interval
+	}
+
+	if !sub.SendControl(ack) {
+		h.logger.Warn("Ack frame not delivered, send queue full", "conn", sub.ID(), "action", msg.Action)
+	}
+}
+
+// applyBulk (un)subscribes every symbol of the message and answers with a single result
+// frame, so a partly rejected list does not turn into a stream of error frames.
+func (h *WebSocketHandler) applyBulk(ctx context.Context, sub *websocket.Subscriber, msg *controlMessage) {
+	results := make(map[string]errorCode, len(msg.Symbols))
+	applied := false
+	for _, symbol := range msg.Symbols {
+		if _, seen := results[symbol]; seen {
+			continue
+		}
+
+		err := h.changeSubscription(ctx, sub, msg, symbol)
+		if protoErr := h.symbolError(sub, msg.Action, symbol, err); protoErr != nil {
+			results[symbol] = protoErr.Code
+			continue
+		}
+		results[symbol] = resultOK
+		applied = true
+	}
+
+	if applied && msg.Action == actionSubscribe {
+		applySubscribeOptions(sub, msg)
+	}
+
+	subscribed := len(sub.Symbols())
+	if msg.Channel == channelKline {
+		subscribed = len(sub.Klines())
+	}
+	result := resultMessage{
+		Type:       messageTypeResult,
+		Action:     msg.Action,
+		Results:    results,
+		Subscribed: subscribed,
+		RequestID:  msg.RequestID,
+	}
+	if !sub.SendControl(result) {
+		h.logger.Warn("Result frame not delivered, send queue full", "conn", sub.ID(), "action", msg.Action)
+	}
+}
+
+// changeSubscription applies a subscribe or unsubscribe of symbol on the message's channel.
+func (h *WebSocketHandler) changeSubscription(
+	ctx context.Context,
+	sub *websocket.Subscriber,
+	msg *controlMessage,
+	symbol string,
+) error {
+	kline := msg.Channel == channelKline
+	if msg.Action == actionSubscribe && h.maxSubscriptions > 0 &&
+		len(sub.Symbols())+len(sub.Klines()) >= h.maxSubscriptions {
+		return errSubscriptionCap
+	}
+
+	switch {
+	case msg.Action == actionSubscribe && kline:
+		return h.dataService.AddKlineSubscriber(ctx, symbol, msg.interval, sub)
+	case msg.Action == actionSubscribe:
+		return h.dataService.AddSubscriber(ctx, symbol, sub)
+	case kline:
+		return h.dataService.RemoveKlineSubscriber(ctx, symbol, msg.interval, sub)
+	default:
+		return h.dataService.RemoveSubscriber(ctx, symbol, sub)
+	}
+}
+
+// applySubscribeOptions applies the delivery options carried by a subscribe message.
+func applySubscribeOptions(sub *websocket.Subscriber, msg *controlMessage) {
+	if msg.Fields != nil {
+		sub.SetFields(msg.Fields)
+	}
+	if msg.Batch != nil {
+		sub.SetBatching(*msg.Batch)
+	}
+	if msg.Formatted != nil {
+		sub.SetFormatted(*msg.Formatted)
+	}
+	if msg.TimeFormat != "" {
+		sub.SetTimeFormat(msg.TimeFormat)
+	}
+	if msg.MaxRate != nil {
+		sub.SetMaxRate(*msg.MaxRate)
+	}
+}
+
+// symbolError maps the outcome of (un)subscribing symbol to the error reported to the client.
+func (h *WebSocketHandler) symbolError(
+	sub *websocket.Subscriber,
+	action, symbol string,
+	err error,
+) *protocolError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, services.ErrTradingPairNotFound):
+		return &protocolError{Code: codeInvalidSymbol, Message: "unknown symbol " + symbol}
+	case errors.Is(err, services.ErrAlreadySubscribed):
+		return &protocolError{Code: codeAlreadySubscribed, Message: "already subscribed to " + symbol}
+	case errors.Is(err, services.ErrNotSubscribed):
+		return &protocolError{Code: codeNotSubscribed, Message: "not subscribed to " + symbol}
+	case errors.Is(err, services.ErrUnsupportedInterval):
+		return &protocolError{Code: codeInvalidInterval, Message: "unsupported interval, see /api/meta"}
+	case errors.Is(err, errSubscriptionCap):
+		return &protocolError{Code: codeSubscriptionCap,
+			Message: fmt.Sprintf("subscription cap of %d reached, unsubscribe first", h.maxSubscriptions)}
+	default:
+		h.logger.Error("Error applying control message",
+			"conn", sub.ID(), "action", action, "symbol", symbol, "error", err)
+		return &protocolError{Code: codeInternalError, Message: "could not apply " + action}
 	}
 }
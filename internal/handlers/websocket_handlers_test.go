@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+func TestRejectedMessageKeepsConnection(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	tests := []struct {
+		message  string
+		wantCode errorCode
+	}{
+		{`not json`, codeInvalidMessage},
+		{`{"action":"trade"}`, codeUnknownAction},
+		{`{"action":"subscribe"}`, codeMissingField},
+		{`{"action":"setFields","fields":["volume"]}`, codeInvalidField},
+		{`{"action":"subscribe","symbol":"ETHUSDT","channel":"kline","interval":"7m"}`, codeInvalidInterval},
+		{`{"action":"subscribe","symbol":"NOPEUSDT"}`, codeInvalidSymbol},
+		{`{"action":"subscribe","symbol":"BTCUSDT"}`, codeAlreadySubscribed},
+		{`{"action":"unsubscribe","symbol":"ETHUSDT"}`, codeNotSubscribed},
+		{`{"action":"subscribe","symbol":"ETHUSDT","channel":"trades"}`, codeUnknownChannel},
+	}
+	for _, tt := range tests {
+		send(t, conn, tt.message)
+		frame := readFrame(t, conn, messageTypeError)
+		if frame["code"] != float64(tt.wantCode) {
+			t.Errorf("%s: got code %v (%v), want %d", tt.message, frame["code"], frame["message"], tt.wantCode)
+		}
+		if frame["message"] == "" {
+			t.Errorf("%s: error frame has no message", tt.message)
+		}
+	}
+
+	// The connection still works after the rejected messages
+	send(t, conn, `{"action":"subscribe","symbol":"ETHUSDT"}`)
+	if ack := readFrame(t, conn, messageTypeSubscribed); ack["symbol"] != "ETHUSDT" {
+		t.Errorf("got ack %v, want ETHUSDT", ack)
+	}
+}
+
+func TestBulkSubscribeResults(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+	s.addPair(t, "SOLUSDT", 150)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	tests := []struct {
+		message        string
+		wantResults    map[string]any
+		wantSubscribed float64
+	}{
+		{
+			`{"action":"subscribe","symbols":["ETHUSDT","BTCUSDT","NOPEUSDT","ETHUSDT"],"requestId":"r1"}`,
+			map[string]any{"ETHUSDT": float64(resultOK), "BTCUSDT": float64(codeAlreadySubscribed), "NOPEUSDT": float64(codeInvalidSymbol)},
+			2,
+		},
+		{
+			`{"action":"unsubscribe","symbols":["ETHUSDT","SOLUSDT"],"requestId":"r2"}`,
+			map[string]any{"ETHUSDT": float64(resultOK), "SOLUSDT": float64(codeNotSubscribed)},
+			1,
+		},
+	}
+	for _, tt := range tests {
+		send(t, conn, tt.message)
+		frame := readFrame(t, conn, messageTypeResult)
+
+		results, _ := frame["results"].(map[string]any)
+		if len(results) != len(tt.wantResults) {
+			t.Errorf("%s: got results %v, want %v", tt.message, results, tt.wantResults)
+		}
+		for symbol, want := range tt.wantResults {
+			if results[symbol] != want {
+				t.Errorf("%s: %s got %v, want %v", tt.message, symbol, results[symbol], want)
+			}
+		}
+		if frame["subscribed"] != tt.wantSubscribed {
+			t.Errorf("%s: subscribed to %v, want %v", tt.message, frame["subscribed"], tt.wantSubscribed)
+		}
+	}
+}
+
+func TestSubscriptionCap(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.WebSocket.MaxSubscriptions = 2 })
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+	s.addPair(t, "SOLUSDT", 150)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	send(t, conn, `{"action":"subscribe","symbol":"ETHUSDT"}`)
+	readFrame(t, conn, messageTypeSubscribed)
+
+	// Kline subscriptions count towards the cap too
+	tests := []string{
+		`{"action":"subscribe","symbol":"SOLUSDT"}`,
+		`{"action":"subscribe","symbol":"SOLUSDT","channel":"kline","interval":"5m"}`,
+	}
+	for _, message := range tests {
+		send(t, conn, message)
+		if frame := readFrame(t, conn, messageTypeError); frame["code"] != float64(codeSubscriptionCap) {
+			t.Errorf("%s: got %v, want code %d", message, frame, codeSubscriptionCap)
+		}
+	}
+	send(t, conn, `{"action":"subscribe","symbols":["SOLUSDT"]}`)
+	if results, _ := readFrame(t, conn, messageTypeResult)["results"].(map[string]any); results["SOLUSDT"] != float64(codeSubscriptionCap) {
+		t.Errorf("got results %v, want SOLUSDT rejected with %d", results, codeSubscriptionCap)
+	}
+
+	// Unsubscribing makes room again
+	send(t, conn, `{"action":"unsubscribe","symbol":"ETHUSDT"}`)
+	readFrame(t, conn, messageTypeUnsubscribed)
+	send(t, conn, `{"action":"subscribe","symbol":"SOLUSDT"}`)
+	readFrame(t, conn, messageTypeSubscribed)
+}
+
+func TestControlRateLimit(t *testing.T) {
+	const limit = 3
+	s := newTestServer(t, func(cfg *config.Config) { cfg.WebSocket.MaxControlRate = limit })
+	s.addPair(t, "BTCUSDT", 50000)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	for i := range limit + 1 {
+		send(t, conn, fmt.Sprintf(`{"action":"setFields","fields":["symbol"],"requestId":"%d"}`, i))
+	}
+	frame := readFrame(t, conn, messageTypeError)
+	if frame["code"] != float64(codeRateLimited) || frame["requestId"] != strconv.Itoa(limit) {
+		t.Errorf("got %v, want message %d rejected with code %d", frame, limit, codeRateLimited)
+	}
+
+	// The connection stays open and accepts messages once the window has passed
+	time.Sleep(time.Second)
+	send(t, conn, `{"action":"unsubscribe","symbol":"BTCUSDT"}`)
+	readFrame(t, conn, messageTypeUnsubscribed)
+}
+
+func TestSymbolError(t *testing.T) {
+	h := &WebSocketHandler{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), maxSubscriptions: 2}
+	sub := websocket.NewSubscriber(nil, config.WebSocketConfig{SendQueueSize: config.MinSendQueueSize}, nil, h.logger)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode errorCode
+	}{
+		{"unknown pair", fmt.Errorf("adding subscriber: %w", services.ErrTradingPairNotFound), codeInvalidSymbol},
+		{"already subscribed", services.ErrAlreadySubscribed, codeAlreadySubscribed},
+		{"not subscribed", services.ErrNotSubscribed, codeNotSubscribed},
+		{"unsupported interval", services.ErrUnsupportedInterval, codeInvalidInterval},
+		{"subscription cap", errSubscriptionCap, codeSubscriptionCap},
+		{"unexpected failure", errors.New("disk on fire"), codeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			protoErr := h.symbolError(sub, actionSubscribe, "BTCUSDT", tt.err)
+			if protoErr == nil || protoErr.Code != tt.wantCode || protoErr.Message == "" {
+				t.Errorf("got %v, want code %d", protoErr, tt.wantCode)
+			}
+		})
+	}
+	if protoErr := h.symbolError(sub, actionSubscribe, "BTCUSDT", nil); protoErr != nil {
+		t.Errorf("got %v for an applied change, want none", protoErr)
+	}
+}
+
+func TestUnknownChannelListsSupportedChannels(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	send(t, conn, `{"action":"subscribe","symbol":"BTCUSDT","channel":"trades"}`)
+	frame := readFrame(t, conn, messageTypeError)
+	message, _ := frame["message"].(string)
+	for _, channel := range supportedChannels {
+		if !strings.Contains(message, channel) {
+			t.Errorf("message %q does not list the %s channel", message, channel)
+		}
+	}
+}
+
+func TestReplyFramesEchoRequestID(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+	conn := s.dial(t, "/ws/BTCUSDT")
+
+	tests := []struct {
+		name      string
+		message   string
+		frameType string
+		want      any // Echoed requestId, nil when the frame must not carry one.
+	}{
+		{"subscribed", `{"action":"subscribe","symbol":"ETHUSDT","requestId":"sub-1"}`, messageTypeSubscribed, "sub-1"},
+		{"unsubscribed", `{"action":"unsubscribe","symbol":"ETHUSDT","requestId":"unsub-1"}`, messageTypeUnsubscribed, "unsub-1"},
+		{"bulk result", `{"action":"subscribe","symbols":["ETHUSDT"],"requestId":"bulk-1"}`, messageTypeResult, "bulk-1"},
+		{"rejected by the service", `{"action":"subscribe","symbol":"NOPEUSDT","requestId":"err-1"}`, messageTypeError, "err-1"},
+		{"failed validation", `{"action":"trade","requestId":"err-2"}`, messageTypeError, "err-2"},
+		{"unparsable", `{"action":"subscribe","extra":1,"requestId":"err-3"}`, messageTypeError, "err-3"},
+		{"without request ID", `{"action":"unsubscribe","symbol":"ETHUSDT"}`, messageTypeUnsubscribed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send(t, conn, tt.message)
+			if frame := readFrame(t, conn, tt.frameType); frame["requestId"] != tt.want {
+				t.Errorf("got %v, want requestId %v", frame, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormattedUpdates(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.addPair(t, "BTCUSDT", 50000)
+	s.addPair(t, "ETHUSDT", 3000)
+
+	// readUpdate returns the next price update of symbol.
+	readUpdate := func(t *testing.T, conn *gorilla.Conn, symbol string) map[string]any {
+		t.Helper()
+		if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+			t.Fatalf("setting read deadline: %v", err)
+		}
+		for {
+			var update map[string]any
+			if err := conn.ReadJSON(&update); err != nil {
+				t.Fatalf("waiting for a %s update: %v", symbol, err)
+			}
+			if update["type"] == nil && update["symbol"] == symbol {
+				return update
+			}
+		}
+	}
+
+	formatted := s.dial(t, "/ws/ETHUSDT")
+	send(t, formatted, `{"action":"subscribe","symbol":"BTCUSDT","formatted":true}`)
+	readFrame(t, formatted, messageTypeSubscribed)
+	plain := s.dial(t, "/ws/BTCUSDT")
+
+	update := readUpdate(t, formatted, "BTCUSDT")
+	for _, field := range []string{"lastPriceStr", "markPriceStr", "priceChangeStr"} {
+		if _, ok := update[field].(string); !ok {
+			t.Errorf("formatted update %v lacks %s", update, field)
+		}
+	}
+	if price, _ := update["lastPriceStr"].(string); !strings.Contains(price, ".") || len(price)-strings.Index(price, ".")-1 != 2 {
+		t.Errorf("lastPriceStr = %q, want two decimals for a pair around 50000", price)
+	}
+
+	if update := readUpdate(t, plain, "BTCUSDT"); update["lastPriceStr"] != nil {
+		t.Errorf("plain subscriber got formatted fields: %v", update)
+	}
+}
+
+func TestWelcomeFrame(t *testing.T) {
+	tests := []struct {
+		name         string
+		welcome      bool
+		subprotocols []string
+	}{
+		{"enabled", true, nil},
+		{"enabled with subprotocol", true, []string{websocket.Protocol}},
+		{"disabled", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(cfg *config.Config) { cfg.WebSocket.Welcome = tt.welcome })
+			s.addPair(t, "BTCUSDT", 50000)
+
+			dialer := gorilla.Dialer{Subprotocols: tt.subprotocols}
+			before := time.Now().UnixMilli()
+			conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/ws/BTCUSDT", nil)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			if len(tt.subprotocols) > 0 && resp.Header.Get("Sec-WebSocket-Protocol") != websocket.Protocol {
+				t.Errorf("negotiated %q, want %s", resp.Header.Get("Sec-WebSocket-Protocol"), websocket.Protocol)
+			}
+
+			if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			var first map[string]any
+			if err := conn.ReadJSON(&first); err != nil {
+				t.Fatalf("reading first frame: %v", err)
+			}
+			if !tt.welcome {
+				if first["type"] == messageTypeWelcome {
+					t.Errorf("welcome frame sent while disabled")
+				}
+				return
+			}
+
+			if first["type"] != messageTypeWelcome {
+				t.Fatalf("first frame %v, want the welcome frame", first)
+			}
+			if id, _ := first["connectionId"].(string); id == "" {
+				t.Errorf("welcome frame has no connection ID: %v", first)
+			}
+			if serverTime, _ := first["serverTime"].(float64); int64(serverTime) < before || int64(serverTime) > time.Now().UnixMilli() {
+				t.Errorf("serverTime %v outside the connection time", first["serverTime"])
+			}
+			if first["protocol"] != websocket.Protocol {
+				t.Errorf("protocol = %v, want %s", first["protocol"], websocket.Protocol)
+			}
+			channels, _ := first["supportedChannels"].([]any)
+			if len(channels) != len(supportedChannels) {
+				t.Fatalf("supportedChannels = %v, want %v", channels, supportedChannels)
+			}
+			for i, channel := range supportedChannels {
+				if channels[i] != channel {
+					t.Errorf("supportedChannels = %v, want %v", channels, supportedChannels)
+				}
+			}
+		})
+	}
+}
+
+func TestDrainingRefusesConnections(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.WebSocket.RetryAfterBase = 2 * time.Second
+		cfg.WebSocket.RetryAfterJitter = 3 * time.Second
+	})
+	s.addPair(t, "BTCUSDT", 50000)
+	s.manager.BeginDrain(time.Second)
+
+	seen := make(map[int]bool)
+	for range 50 {
+		rec := s.serve(httptest.NewRequest(http.MethodGet, "/ws/BTCUSDT", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status %d, want 503 while draining", rec.Code)
+		}
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retryAfter < 2 || retryAfter > 5 {
+			t.Fatalf("Retry-After %q, want 2 to 5 seconds", rec.Header().Get("Retry-After"))
+		}
+		seen[retryAfter] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Retry-After always %v, want it spread by the jitter", seen)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+	"github.com/sand/crypto-trading-app/backend/internal/services"
+)
+
+// Control message actions a client may send.
+const (
+	actionSubscribe   = "subscribe"
+	actionUnsubscribe = "unsubscribe"
+	actionSetFields   = "setFields"
+)
+
+// Channels a client can subscribe to.
+const (
+	channelCandles = "candles" // Price and live base candle updates, the default.
+	channelKline   = "kline"   // Bars of one candle interval, with an event when a bar closes.
+)
+
+// supportedChannels lists the channels clients may subscribe to, control messages naming
+// any other channel are rejected. A new channel only has to be added here.
+var supportedChannels = []string{channelCandles, channelKline}
+
+// errorCode identifies why a client message was rejected. Codes are part of the protocol and
+// documented in the README, a code keeps its number once released.
+type errorCode int
+
+// Error codes sent to clients in error frames. 40xx codes reject the message itself, 41xx
+// its subscription change, 42xx a client over its limits and 50xx a server failure.
+const (
+	codeInvalidMessage    errorCode = 4000
+	codeUnknownAction     errorCode = 4001
+	codeUnknownChannel    errorCode = 4002
+	codeMissingField      errorCode = 4003
+	codeInvalidField      errorCode = 4004
+	codeInvalidInterval   errorCode = 4005
+	codeInvalidSymbol     errorCode = 4100
+	codeAlreadySubscribed errorCode = 4101
+	codeNotSubscribed     errorCode = 4102
+	codeSubscriptionCap   errorCode = 4200
+	codeRateLimited       errorCode = 4201
+	codeInternalError     errorCode = 5000
+)
+
+// Types of the frames the server sends in reply to control messages.
+const (
+	messageTypeError        = "error"        // A rejected client message.
+	messageTypeResult       = "result"       // Per-symbol outcome of a bulk (un)subscribe.
+	messageTypeSubscribed   = "subscribed"   // Acknowledges a subscribe.
+	messageTypeUnsubscribed = "unsubscribed" // Acknowledges an unsubscribe.
+	messageTypeWelcome      = "welcome"      // First frame of a connection.
+)
+
+// resultOK is the per-symbol outcome of a bulk (un)subscribe that was applied.
+const resultOK errorCode = 0
+
+// controlMessage is a client message adjusting its subscriptions.
+type controlMessage struct {
+	Action  string   `json:"action"`            // One of the action constants.
+	Channel string   `json:"channel,omitempty"` // Data stream to (un)subscribe, candles when empty.
+	Symbol  string   `json:"symbol,omitempty"`  // Pair to (un)subscribe.
+	Symbols []string `json:"symbols,omitempty"` // Pairs to (un)subscribe in bulk, answered with one result frame.
+	Fields  []string `json:"fields,omitempty"`  // Broadcast fields to receive, empty for the full payload.
+	Batch   *bool    `json:"batch,omitempty"`   // Opt into coalesced batch frames (subscribe only).
+
+	TimeFormat string `json:"timeFormat,omitempty"` // Candle time format (subscribe only).
+	MaxRate    *int   `json:"maxRate,omitempty"`    // Update frames per second, 0 for no limit (subscribe only).
+	Formatted  *bool  `json:"formatted,omitempty"`  // Add display strings such as priceChangeStr (subscribe only).
+	Interval   string `json:"interval,omitempty"`   // Bar interval of the kline channel, e.g. 5m.
+	RequestID  string `json:"requestId,omitempty"`  // Client reference echoed in the reply frame.
+
+	interval time.Duration // Parsed Interval.
+}
+
+// errorMessage is the frame sent back when a client message is rejected.
+type errorMessage struct {
+	Type      string    `json:"type"`
+	Code      errorCode `json:"code"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"requestId,omitempty"`
+}
+
+// resultMessage is the frame answering a bulk (un)subscribe. Results maps each requested
+// symbol to resultOK or the error code it was rejected with.
+type resultMessage struct {
+	Type       string               `json:"type"`
+	Action     string               `json:"action"`
+	Results    map[string]errorCode `json:"results"`
+	Subscribed int                  `json:"subscribed"` // Symbols the connection is subscribed to afterwards.
+	RequestID  string               `json:"requestId,omitempty"`
+}
+
+// ackMessage is the frame confirming a single-symbol (un)subscribe.
+type ackMessage struct {
+	Type          string `json:"type"`
+	Channel       string `json:"channel"`
+	Symbol        string `json:"symbol"`
+	Interval      string `json:"interval,omitempty"` // Set for the kline channel.
+	Subscriptions int    `json:"subscriptions"`      // Subscriptions of the connection afterwards, all channels.
+	RequestID     string `json:"requestId,omitempty"`
+}
+
+// welcomeMessage opens a connection, telling the client its ID and what the server offers.
+type welcomeMessage struct {
+	Type              string   `json:"type"`
+	ConnectionID      string   `json:"connectionId"`
+	ServerTime        int64    `json:"serverTime"` // Milliseconds since the epoch.
+	Protocol          string   `json:"protocol"`
+	SupportedChannels []string `json:"supportedChannels"`
+}
+
+// protocolError describes why a control message was rejected.
+type protocolError struct {
+	Code    errorCode
+	Message string
+}
+
+func (e *protocolError) Error() string {
+	return strconv.Itoa(int(e.Code)) + ": " + e.Message
+}
+
+// frame converts the error into the message sent to the client.
+func (e *protocolError) frame(requestID string) errorMessage {
+	return errorMessage{Type: messageTypeError, Code: e.Code, Message: e.Message, RequestID: requestID}
+}
+
+// peekRequestID extracts the requestId of a message that failed validation, so even its
+// error frame can be matched to the request. It is empty when the message isn't a JSON object
+// with a string requestId.
+func peekRequestID(data []byte) string {
+	var msg struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return ""
+	}
+	return msg.RequestID
+}
+
+// parseControlMessage strictly decodes and validates a client message.
+func parseControlMessage(data []byte) (*controlMessage, *protocolError) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var msg controlMessage
+	if err := decoder.Decode(&msg); err != nil {
+		return nil, &protocolError{Code: codeInvalidMessage, Message: fmt.Sprintf("malformed message: %v", err)}
+	}
+	if decoder.More() {
+		return nil, &protocolError{Code: codeInvalidMessage, Message: "unexpected data after message"}
+	}
+
+	switch msg.Action {
+	case "":
+		return nil, &protocolError{Code: codeMissingField, Message: "action is required"}
+	case actionSubscribe, actionUnsubscribe:
+		if msg.Symbol == "" && len(msg.Symbols) == 0 {
+			return nil, &protocolError{Code: codeMissingField, Message: "symbol or symbols is required for " + msg.Action}
+		}
+		if msg.Symbol != "" && len(msg.Symbols) > 0 {
+			return nil, &protocolError{Code: codeInvalidMessage, Message: "symbol and symbols are mutually exclusive"}
+		}
+	case actionSetFields:
+		if msg.Fields == nil {
+			return nil, &protocolError{Code: codeMissingField, Message: "fields is required for " + msg.Action}
+		}
+		if msg.Symbols != nil {
+			return nil, &protocolError{Code: codeInvalidMessage, Message: "symbols are not accepted by " + msg.Action}
+		}
+	default:
+		return nil, &protocolError{Code: codeUnknownAction, Message: fmt.Sprintf("unknown action %q", msg.Action)}
+	}
+
+	if msg.Channel != "" && !slices.Contains(supportedChannels, msg.Channel) {
+		return nil, &protocolError{Code: codeUnknownChannel, Message: fmt.Sprintf("unknown channel %q, supported channels: %s",
+			msg.Channel, strings.Join(supportedChannels, ", "))}
+	}
+
+	if protoErr := parseInterval(&msg); protoErr != nil {
+		return nil, protoErr
+	}
+
+	if msg.MaxRate != nil && *msg.MaxRate < 0 {
+		return nil, &protocolError{Code: codeInvalidMessage, Message: "maxRate must not be negative"}
+	}
+
+	if msg.TimeFormat != "" && !models.IsTimeFormat(msg.TimeFormat) {
+		return nil, &protocolError{Code: codeInvalidMessage, Message: "timeFormat must be millis, seconds or iso"}
+	}
+
+	if err := services.ValidateBroadcastFields(msg.Fields); err != nil {
+		return nil, &protocolError{Code: codeInvalidField, Message: err.Error()}
+	}
+
+	return &msg, nil
+}
+
+// parseInterval checks the interval of a kline (un)subscribe. Other messages must not carry one.
+func parseInterval(msg *controlMessage) *protocolError {
+	if msg.Channel != channelKline || msg.Action == actionSetFields {
+		if msg.Interval != "" {
+			return &protocolError{Code: codeInvalidMessage, Message: "interval is only accepted with the kline channel"}
+		}
+		return nil
+	}
+
+	if msg.Interval == "" {
+		return &protocolError{Code: codeMissingField, Message: "interval is required for the kline channel"}
+	}
+	interval, err := time.ParseDuration(msg.Interval)
+	if err != nil || interval <= 0 {
+		return &protocolError{Code: codeInvalidInterval, Message: "interval must be a duration such as 5m"}
+	}
+	msg.interval = interval
+	return nil
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestParseControlMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantCode errorCode // resultOK when the message is valid.
+	}{
+		{"subscribe", `{"action":"subscribe","symbol":"BTCUSDT"}`, resultOK},
+		{"unsubscribe", `{"action":"unsubscribe","symbol":"BTCUSDT"}`, resultOK},
+		{"subscribe with options", `{"action":"subscribe","symbol":"BTCUSDT","fields":["lastPrice"],"timeFormat":"iso","maxRate":2}`, resultOK},
+		{"set fields", `{"action":"setFields","fields":["symbol","lastPrice"]}`, resultOK},
+		{"set empty fields", `{"action":"setFields","fields":[]}`, resultOK},
+		{"not JSON", `subscribe BTCUSDT`, codeInvalidMessage},
+		{"not an object", `["subscribe"]`, codeInvalidMessage},
+		{"unknown property", `{"action":"subscribe","symbol":"BTCUSDT","extra":1}`, codeInvalidMessage},
+		{"wrong type", `{"action":"subscribe","symbol":42}`, codeInvalidMessage},
+		{"data after message", `{"action":"subscribe","symbol":"BTCUSDT"} {}`, codeInvalidMessage},
+		{"missing action", `{"symbol":"BTCUSDT"}`, codeMissingField},
+		{"unknown action", `{"action":"trade","symbol":"BTCUSDT"}`, codeUnknownAction},
+		{"subscribe without symbol", `{"action":"subscribe"}`, codeMissingField},
+		{"set fields without fields", `{"action":"setFields"}`, codeMissingField},
+		{"unknown field", `{"action":"setFields","fields":["volume"]}`, codeInvalidField},
+		{"negative max rate", `{"action":"subscribe","symbol":"BTCUSDT","maxRate":-1}`, codeInvalidMessage},
+		{"unknown time format", `{"action":"subscribe","symbol":"BTCUSDT","timeFormat":"rfc822"}`, codeInvalidMessage},
+		{"candles channel", `{"action":"subscribe","symbol":"BTCUSDT","channel":"candles"}`, resultOK},
+		{"unknown channel", `{"action":"subscribe","symbol":"BTCUSDT","channel":"trades"}`, codeUnknownChannel},
+		{"unknown channel on unsubscribe", `{"action":"unsubscribe","symbol":"BTCUSDT","channel":"depth"}`, codeUnknownChannel},
+		{"channel names are case sensitive", `{"action":"subscribe","symbol":"BTCUSDT","channel":"Kline","interval":"5m"}`, codeUnknownChannel},
+		{"kline", `{"action":"subscribe","symbol":"BTCUSDT","channel":"kline","interval":"5m"}`, resultOK},
+		{"kline unsubscribe", `{"action":"unsubscribe","symbol":"BTCUSDT","channel":"kline","interval":"1h"}`, resultOK},
+		{"kline without interval", `{"action":"subscribe","symbol":"BTCUSDT","channel":"kline"}`, codeMissingField},
+		{"kline with invalid interval", `{"action":"subscribe","symbol":"BTCUSDT","channel":"kline","interval":"soon"}`, codeInvalidInterval},
+		{"kline with zero interval", `{"action":"subscribe","symbol":"BTCUSDT","channel":"kline","interval":"0s"}`, codeInvalidInterval},
+		{"interval without kline", `{"action":"subscribe","symbol":"BTCUSDT","interval":"5m"}`, codeInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, protoErr := parseControlMessage([]byte(tt.data))
+			if tt.wantCode == resultOK {
+				if protoErr != nil {
+					t.Fatalf("got error %v, want none", protoErr)
+				}
+				if msg == nil {
+					t.Fatal("got no message")
+				}
+				return
+			}
+			if protoErr == nil {
+				t.Fatalf("got no error, want %d", tt.wantCode)
+			}
+			if protoErr.Code != tt.wantCode {
+				t.Errorf("got code %d (%s), want %d", protoErr.Code, protoErr.Message, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestParseBulkControlMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantCode errorCode
+	}{
+		{"bulk subscribe", `{"action":"subscribe","symbols":["BTCUSDT","ETHUSDT"]}`, resultOK},
+		{"bulk unsubscribe", `{"action":"unsubscribe","symbols":["BTCUSDT"]}`, resultOK},
+		{"symbol and symbols", `{"action":"subscribe","symbol":"BTCUSDT","symbols":["ETHUSDT"]}`, codeInvalidMessage},
+		{"empty symbols", `{"action":"subscribe","symbols":[]}`, codeMissingField},
+		{"symbols with set fields", `{"action":"setFields","fields":[],"symbols":["BTCUSDT"]}`, codeInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, protoErr := parseControlMessage([]byte(tt.data))
+			switch {
+			case tt.wantCode == resultOK && protoErr != nil:
+				t.Fatalf("got error %v, want none", protoErr)
+			case tt.wantCode != resultOK && (protoErr == nil || protoErr.Code != tt.wantCode):
+				t.Fatalf("got %v, want %d", protoErr, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPeekRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"valid message", `{"action":"subscribe","symbol":"BTCUSDT","requestId":"r1"}`, "r1"},
+		{"invalid message", `{"action":"trade","extra":true,"requestId":"r2"}`, "r2"},
+		{"no request ID", `{"action":"subscribe"}`, ""},
+		{"numeric request ID", `{"action":"subscribe","requestId":7}`, ""},
+		{"not JSON", `subscribe r3`, ""},
+		{"not an object", `["r4"]`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peekRequestID([]byte(tt.data)); got != tt.want {
+				t.Errorf("peekRequestID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
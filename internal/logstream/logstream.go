@@ -0,0 +1,120 @@
+package logstream
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// clientBuffer is how many log lines wait for one slow client before new ones are dropped.
+const clientBuffer = 256
+
+// Hub fans formatted log lines out to the connected clients. A client that doesn't keep up
+// loses lines instead of blocking the logger.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	count   atomic.Int64 // Number of clients, read without the lock on every log call.
+}
+
+// Client receives the log lines written while it is subscribed.
+type Client struct {
+	lines   chan []byte
+	dropped atomic.Int64
+}
+
+// NewHub creates a hub without clients.
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+// Subscribe registers a client receiving every log line from now on.
+func (h *Hub) Subscribe() *Client {
+	c := &Client{lines: make(chan []byte, clientBuffer)}
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.count.Store(int64(len(h.clients)))
+	h.mu.Unlock()
+	return c
+}
+
+// Unsubscribe stops delivering lines to the client.
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.count.Store(int64(len(h.clients)))
+	h.mu.Unlock()
+}
+
+// Write delivers one formatted log line to every client. It never blocks: a line that
+// doesn't fit in a client's buffer is counted as dropped for that client.
+func (h *Hub) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(bytes.Clone(p), "\n")
+
+	h.mu.RLock()
+	for c := range h.clients {
+		select {
+		case c.lines <- line:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+	h.mu.RUnlock()
+	return len(p), nil
+}
+
+// Lines returns the channel of log lines, one JSON object each.
+func (c *Client) Lines() <-chan []byte {
+	return c.lines
+}
+
+// TakeDropped returns how many lines were dropped since the last call and resets the count.
+func (c *Client) TakeDropped() int64 {
+	return c.dropped.Swap(0)
+}
+
+// Handler is a slog.Handler passing records to the wrapped handler and, as JSON, to the
+// clients of a hub.
+type Handler struct {
+	next   slog.Handler
+	stream slog.Handler
+	hub    *Hub
+}
+
+// NewHandler wraps next so its records are also streamed to the clients of hub.
+func NewHandler(next slog.Handler, hub *Hub) *Handler {
+	return &Handler{
+		next:   next,
+		stream: slog.NewJSONHandler(hub, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		hub:    hub,
+	}
+}
+
+// Enabled reports whether the wrapped handler takes the level, the stream shows the same
+// records the server logs.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle logs the record and streams it if any client is connected.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.next.Handle(ctx, r)
+	if h.hub.count.Load() > 0 {
+		// Write never fails, and a streaming problem must not fail the log call
+		_ = h.stream.Handle(ctx, r)
+	}
+	return err
+}
+
+// WithAttrs returns a handler adding attrs to both outputs.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), stream: h.stream.WithAttrs(attrs), hub: h.hub}
+}
+
+// WithGroup returns a handler nesting later attributes under name in both outputs.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), stream: h.stream.WithGroup(name), hub: h.hub}
+}
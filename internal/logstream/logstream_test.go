@@ -0,0 +1,116 @@
+package logstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// receive returns the next line of the client, failing the test when none is waiting.
+func receive(t *testing.T, c *Client) []byte {
+	t.Helper()
+
+	select {
+	case line := <-c.Lines():
+		return line
+	case <-time.After(time.Second):
+		t.Fatal("no line received")
+		return nil
+	}
+}
+
+func TestHubWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"trailing newline trimmed", "{\"msg\":\"a\"}\n", `{"msg":"a"}`},
+		{"no newline", `{"msg":"b"}`, `{"msg":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			first, second := hub.Subscribe(), hub.Subscribe()
+			gone := hub.Subscribe()
+			hub.Unsubscribe(gone)
+
+			input := []byte(tt.input)
+			n, err := hub.Write(input)
+			if err != nil || n != len(tt.input) {
+				t.Fatalf("Write = %d, %v, want %d, nil", n, err, len(tt.input))
+			}
+			// The logger reuses its buffer, clients must hold their own copy
+			copy(input, bytes.Repeat([]byte("x"), len(input)))
+
+			for _, c := range []*Client{first, second} {
+				if got := string(receive(t, c)); got != tt.want {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			}
+			if len(gone.Lines()) != 0 {
+				t.Error("unsubscribed client received a line")
+			}
+		})
+	}
+}
+
+func TestHubDropsLinesForSlowClient(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe()
+
+	for range clientBuffer + 3 {
+		if _, err := hub.Write([]byte("line\n")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if got := len(slow.Lines()); got != clientBuffer {
+		t.Errorf("%d lines buffered, want %d", got, clientBuffer)
+	}
+	if got := slow.TakeDropped(); got != 3 {
+		t.Errorf("dropped %d lines, want 3", got)
+	}
+	if got := slow.TakeDropped(); got != 0 {
+		t.Errorf("dropped count not reset, got %d", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	hub := NewHub()
+	var out bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}), hub))
+
+	// Without clients records only reach the wrapped handler
+	logger.Info("before")
+	client := hub.Subscribe()
+
+	logger.Debug("below the level")
+	logger.With("conn", "abc").WithGroup("req").Info("streamed", "status", 200)
+
+	var record map[string]any
+	if err := json.Unmarshal(receive(t, client), &record); err != nil {
+		t.Fatalf("decoding streamed record: %v", err)
+	}
+	if record["msg"] != "streamed" || record["level"] != "INFO" || record["conn"] != "abc" {
+		t.Errorf("got %v, want the streamed record with its attributes", record)
+	}
+	if req, _ := record["req"].(map[string]any); req["status"] != float64(200) {
+		t.Errorf("got group %v, want status 200 under req", record["req"])
+	}
+	if len(client.Lines()) != 0 {
+		t.Errorf("%d more lines streamed, want the debug record left out", len(client.Lines()))
+	}
+
+	for _, msg := range []string{"msg=before", "msg=streamed"} {
+		if !bytes.Contains(out.Bytes(), []byte(msg)) {
+			t.Errorf("wrapped handler output %q lacks %s", out.String(), msg)
+		}
+	}
+	if bytes.Contains(out.Bytes(), []byte("below the level")) {
+		t.Error("wrapped handler logged a record below its level")
+	}
+}
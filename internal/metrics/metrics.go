@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Histogram bucket layout for request latencies: 1ms doubling up to ~8s.
+const (
+	latencyBucketStart  = 0.001
+	latencyBucketFactor = 2
+	latencyBucketCount  = 14
+)
+
+// statusClassDivisor turns an HTTP status code into its class digit (404 -> 4).
+const statusClassDivisor = 100
+
+// Metrics holds the Prometheus collectors exposed by the server.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	requestsTotal   *prometheus.CounterVec
+	wsDropped       *prometheus.CounterVec
+	wsDisconnects   *prometheus.CounterVec
+	wsWriteErrors   prometheus.Counter
+	wsMessagesSent  *prometheus.CounterVec
+	wsBytesSent     *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them in a dedicated registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "http_request_duration_seconds",
+			Help: "Latency of HTTP API requests by route template.",
+			Buckets: prometheus.ExponentialBuckets(
+				latencyBucketStart, latencyBucketFactor, latencyBucketCount),
+		}, []string{"route", "method"}),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP API requests by route template and status code class.",
+		}, []string{"route", "method", "code_class"}),
+		wsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_messages_dropped_total",
+			Help: "WebSocket updates not delivered to a slow client, by pair symbol, channel and reason.",
+		}, []string{"symbol", "channel", "reason"}),
+		wsDisconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_slow_consumer_disconnects_total",
+			Help: "WebSocket clients disconnected for not keeping up with updates, by reason.",
+		}, []string{"reason"}),
+		wsWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "websocket_write_errors_total",
+			Help: "WebSocket frames that failed to write, each closes its connection.",
+		}),
+		wsMessagesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_messages_sent_total",
+			Help: "WebSocket messages written to clients by channel, updates in a batch frame count one each.",
+		}, []string{"channel"}),
+		wsBytesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "websocket_bytes_sent_total",
+			Help: "Encoded bytes of the WebSocket messages written to clients, by channel.",
+		}, []string{"channel"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.requestsTotal,
+		m.wsDropped,
+		m.wsDisconnects,
+		m.wsWriteErrors,
+		m.wsMessagesSent,
+		m.wsBytesSent,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records one finished HTTP request. route must be the route template,
+// not the concrete path, to keep label cardinality bounded.
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+	m.requestsTotal.WithLabelValues(route, method, statusClass(status)).Inc()
+}
+
+// MessageDropped records a WebSocket message of symbol on channel that was discarded instead
+// of delivered. Connection-level frames have an empty symbol.
+func (m *Metrics) MessageDropped(symbol, channel, reason string) {
+	m.wsDropped.WithLabelValues(symbol, channel, reason).Inc()
+}
+
+// WriteFailed records a WebSocket frame that could not be written.
+func (m *Metrics) WriteFailed() {
+	m.wsWriteErrors.Inc()
+}
+
+// MessageSent records a WebSocket message of size bytes written on channel. channel must be
+// one of the fixed channel names, not client input, to keep label cardinality bounded.
+func (m *Metrics) MessageSent(channel string, size int) {
+	m.wsMessagesSent.WithLabelValues(channel).Inc()
+	m.wsBytesSent.WithLabelValues(channel).Add(float64(size))
+}
+
+// SlowConsumerDisconnected records a WebSocket client closed for falling behind.
+func (m *Metrics) SlowConsumerDisconnected(reason string) {
+	m.wsDisconnects.WithLabelValues(reason).Inc()
+}
+
+// statusClass maps a status code to its class label, e.g. 404 -> "4xx".
+func statusClass(status int) string {
+	return strconv.Itoa(status/statusClassDivisor) + "xx"
+}
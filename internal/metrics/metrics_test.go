@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the metrics exposition of m.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, "2xx"},
+		{http.StatusNoContent, "2xx"},
+		{http.StatusNotFound, "4xx"},
+		{http.StatusTooManyRequests, "4xx"},
+		{http.StatusServiceUnavailable, "5xx"},
+	}
+
+	for _, tt := range tests {
+		if got := statusClass(tt.status); got != tt.want {
+			t.Errorf("statusClass(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("/api/candles/{symbol}", http.MethodGet, http.StatusOK, 3*time.Millisecond)
+	m.ObserveRequest("/api/candles/{symbol}", http.MethodGet, http.StatusNotFound, time.Millisecond)
+	m.ObserveRequest("/api/candles/{symbol}", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`http_requests_total{code_class="2xx",method="GET",route="/api/candles/{symbol}"} 2`,
+		`http_requests_total{code_class="4xx",method="GET",route="/api/candles/{symbol}"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/candles/{symbol}"} 3`,
+		// 1ms doubling: 1 request within 1ms, 2 within 4ms, all 3 within 32ms
+		`http_request_duration_seconds_bucket{method="GET",route="/api/candles/{symbol}",le="0.001"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/candles/{symbol}",le="0.004"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/candles/{symbol}",le="0.032"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestMessageSent(t *testing.T) {
+	m := New()
+	m.MessageSent("candles", 100)
+	m.MessageSent("candles", 50)
+	m.MessageSent("control", 20)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`websocket_messages_sent_total{channel="candles"} 2`,
+		`websocket_messages_sent_total{channel="control"} 1`,
+		`websocket_bytes_sent_total{channel="candles"} 150`,
+		`websocket_bytes_sent_total{channel="control"} 20`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+	if strings.Contains(body, `channel="kline"`) {
+		t.Error("metrics report a channel nothing was sent on")
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+// Tick is a single simulated price update.
+type Tick struct {
+	Time  int64   `json:"time"`  // Time in milliseconds.
+	Price float64 `json:"price"` // Last price after the update.
+}
+
+// TickRing keeps the most recent ticks in a fixed-size ring. It is not safe for concurrent
+// use, the owning pair's mutex guards it.
+type TickRing struct {
+	ticks []Tick
+	next  int  // Index the next tick is written to.
+	full  bool // Whether the ring has wrapped around.
+}
+
+// NewTickRing creates a ring holding up to size ticks.
+func NewTickRing(size int) *TickRing {
+	return &TickRing{ticks: make([]Tick, size)}
+}
+
+// Push stores a tick, replacing the oldest one once the ring is full.
+func (r *TickRing) Push(tick Tick) {
+	r.ticks[r.next] = tick
+	r.next++
+	if r.next == len(r.ticks) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Last returns a copy of up to n of the most recent ticks, oldest first.
+func (r *TickRing) Last(n int) []Tick {
+	count := r.next
+	if r.full {
+		count = len(r.ticks)
+	}
+	n = max(0, min(n, count))
+
+	result := make([]Tick, n)
+	start := r.next - n
+	if start < 0 {
+		// The requested ticks wrap around the end of the ring
+		copied := copy(result, r.ticks[len(r.ticks)+start:])
+		copy(result[copied:], r.ticks[:r.next])
+	} else {
+		copy(result, r.ticks[start:r.next])
+	}
+	return result
+}
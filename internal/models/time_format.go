@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Formats a candle time can be rendered in.
+const (
+	TimeFormatMillis  = "millis"  // Milliseconds since the epoch, the stored form.
+	TimeFormatSeconds = "seconds" // Seconds since the epoch.
+	TimeFormatISO     = "iso"     // RFC 3339 string in UTC.
+)
+
+// IsTimeFormat reports whether format names a supported time format.
+func IsTimeFormat(format string) bool {
+	switch format {
+	case TimeFormatMillis, TimeFormatSeconds, TimeFormatISO:
+		return true
+	default:
+		return false
+	}
+}
+
+// FormatTime renders a millisecond timestamp in the given format. Unknown formats keep milliseconds.
+func FormatTime(ms int64, format string) any {
+	switch format {
+	case TimeFormatSeconds:
+		return ms / int64(time.Second/time.Millisecond)
+	case TimeFormatISO:
+		return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+	default:
+		return ms
+	}
+}
+
+// FormattedCandle is a candle with its time rendered for output; storage keeps milliseconds.
+type FormattedCandle struct {
+	CandleData
+
+	Time any `json:"time"` // Shadows CandleData.Time in JSON.
+}
+
+// WithTimeFormat returns the candle ready to encode with its time in the given format.
+func (c CandleData) WithTimeFormat(format string) FormattedCandle {
+	return FormattedCandle{CandleData: c, Time: FormatTime(c.Time, format)}
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatTime(t *testing.T) {
+	const ms = 1735689600500 // 2025-01-01T00:00:00.5Z
+
+	tests := []struct {
+		name   string
+		format string
+		want   any
+	}{
+		{"millis", TimeFormatMillis, int64(ms)},
+		{"seconds", TimeFormatSeconds, int64(1735689600)},
+		{"iso", TimeFormatISO, "2025-01-01T00:00:00Z"},
+		{"default", "", int64(ms)},
+		{"unknown", "rfc822", int64(ms)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTime(ms, tt.format); got != tt.want {
+				t.Errorf("FormatTime(%d, %q) = %v (%T), want %v (%T)", ms, tt.format, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTimeFormat(t *testing.T) {
+	for _, format := range []string{TimeFormatMillis, TimeFormatSeconds, TimeFormatISO} {
+		if !IsTimeFormat(format) {
+			t.Errorf("IsTimeFormat(%q) = false, want true", format)
+		}
+	}
+	for _, format := range []string{"", "ISO", "rfc822"} {
+		if IsTimeFormat(format) {
+			t.Errorf("IsTimeFormat(%q) = true, want false", format)
+		}
+	}
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	candle := CandleData{Time: 1735689600000, Open: 1, High: 3, Low: 0.5, Close: 2, Volume: 10}
+
+	tests := []struct {
+		format   string
+		wantTime string // Encoded time.
+	}{
+		{TimeFormatMillis, `1735689600000`},
+		{TimeFormatSeconds, `1735689600`},
+		{TimeFormatISO, `"2025-01-01T00:00:00Z"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := json.Marshal(candle.WithTimeFormat(tt.format))
+			if err != nil {
+				t.Fatalf("encoding candle: %v", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("decoding %s: %v", data, err)
+			}
+			if got := string(fields["time"]); got != tt.wantTime {
+				t.Errorf("time = %s, want %s", got, tt.wantTime)
+			}
+			if string(fields["open"]) != "1" || string(fields["close"]) != "2" || string(fields["volume"]) != "10" {
+				t.Errorf("got %s, want the candle's prices and volume kept", data)
+			}
+		})
+	}
+}
@@ -2,8 +2,9 @@ package models
 
 import (
 	"sync"
+	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
 )
 
 // CandleData represents candle data for the chart.
@@ -16,14 +17,36 @@ type CandleData struct {
 	Volume float64 `json:"volume"` // Trading volume.
 }
 
+// PriceRecord is a record price and when it was reached.
+type PriceRecord struct {
+	Price float64 `json:"price"` // Record price.
+	Time  int64   `json:"time"`  // Time in milliseconds.
+}
+
 // TradingPair represents a trading pair.
 type TradingPair struct {
-	Symbol      string                   `json:"symbol"`      // Pair symbol (e.g., BTCUSDT).
-	LastPrice   float64                  `json:"lastPrice"`   // Last price.
-	PriceChange float64                  `json:"priceChange"` // Price change percentage.
-	CandleData  []CandleData             `json:"-"`           // Historical candle data.
-	LastCandle  CandleData               `json:"-"`           // Last candle.
-	Subscribers map[*websocket.Conn]bool `json:"-"`           // WebSocket update subscribers.
-	Mutex       sync.RWMutex             `json:"-"`           // Mutex for safe data access.
-	StopChan    chan struct{}            `json:"-"`           // Channel for stopping goroutines.
+	Symbol       string                         `json:"symbol"`       // Pair symbol (e.g., BTCUSDT).
+	InitialPrice float64                        `json:"initialPrice"` // Base price used when history is generated.
+	Volatility   float64                        `json:"volatility"`   // Multiplier for real-time price variation.
+	Regime       string                         `json:"regime"`       // Current volatility regime (calm/volatile).
+	LastPrice    float64                        `json:"lastPrice"`    // Last price.
+	MarkPrice    float64                        `json:"markPrice"`    // Smoothed price, robust to single-tick wicks.
+	PriceChange  float64                        `json:"priceChange"`  // Price change percentage.
+	LastUpdate   time.Time                      `json:"lastUpdate"`   // When the simulation last ticked the pair.
+	AllTimeHigh  PriceRecord                    `json:"allTimeHigh"`  // Highest price seen, kept when candles are trimmed.
+	AllTimeLow   PriceRecord                    `json:"allTimeLow"`   // Lowest price seen, kept when candles are trimmed.
+	CandleData   []CandleData                   `json:"-"`            // Historical candle data.
+	LastCandle   CandleData                     `json:"-"`            // Last candle.
+	Ticks        *TickRing                      `json:"-"`            // Recent raw price updates.
+	Subscribers  map[*websocket.Subscriber]bool `json:"-"`            // WebSocket update subscribers.
+	Mutex        sync.RWMutex                   `json:"-"`            // Mutex for safe data access.
+	StopChan     chan struct{}                  `json:"-"`            // Channel for stopping goroutines.
+	Removed      bool                           `json:"-"`            // Set under Mutex once the pair is removed.
+
+	// HistoryReplaced is set under Mutex when CandleData was replaced; the simulation then
+	// continues from LastCandle instead of its own current candle.
+	HistoryReplaced bool `json:"-"`
+
+	// Subscribers of the pair's bars by candle interval, guarded by Mutex like Subscribers.
+	KlineSubscribers map[time.Duration]map[*websocket.Subscriber]bool `json:"-"`
 }
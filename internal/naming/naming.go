@@ -0,0 +1,91 @@
+package naming
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// JSON key styles of API output.
+const (
+	StyleCamel = "camel" // Keys as declared, e.g. lastPrice.
+	StyleSnake = "snake" // Keys converted to snake_case, e.g. last_price.
+)
+
+// Transform returns v re-keyed in the given style, ready to be encoded as JSON.
+// StyleCamel returns v unchanged.
+func Transform(v any, style string) (any, error) {
+	if style != StyleSnake {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnake(data)
+}
+
+// TransformJSON re-keys an encoded JSON document in the given style.
+func TransformJSON(data []byte, style string) ([]byte, error) {
+	if style != StyleSnake {
+		return data, nil
+	}
+
+	v, err := decodeSnake(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// decodeSnake decodes a JSON document and converts all object keys to snake_case.
+func decodeSnake(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep numbers exactly as the original encoding wrote them
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return snakeKeys(v), nil
+}
+
+// snakeKeys converts the object keys of a decoded JSON value recursively.
+func snakeKeys(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(value))
+		for key, item := range value {
+			converted[SnakeCase(key)] = snakeKeys(item)
+		}
+		return converted
+	case []any:
+		for i, item := range value {
+			value[i] = snakeKeys(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// SnakeCase converts a camelCase key to snake_case. Keys without lowercase letters,
+// such as symbols used as map keys (BTCUSDT), are returned unchanged.
+func SnakeCase(key string) string {
+	if strings.ToUpper(key) == key {
+		return key
+	}
+
+	var b strings.Builder
+	var prev rune
+	for i, r := range key {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+		prev = r
+	}
+	return b.String()
+}
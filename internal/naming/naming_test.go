@@ -0,0 +1,106 @@
+package naming
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// pairPayload is shaped like the API's pair updates: nested objects, lists and symbols used
+// as map keys.
+type pairPayload struct {
+	Symbol       string             `json:"symbol"`
+	LastPrice    float64            `json:"lastPrice"`
+	Change24h    float64            `json:"change24h"`
+	LastCandle   candlePayload      `json:"lastCandle"`
+	RecentTrades []candlePayload    `json:"recentTrades"`
+	IndexWeights map[string]float64 `json:"indexWeights"`
+}
+
+type candlePayload struct {
+	Time      int64   `json:"time"`
+	OpenPrice float64 `json:"openPrice"`
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"symbol", "symbol"},
+		{"lastPrice", "last_price"},
+		{"allTimeHigh", "all_time_high"},
+		{"change24h", "change24h"},
+		{"change24hHigh", "change24h_high"},
+		{"requestId", "request_id"},
+		{"BTCUSDT", "BTCUSDT"},
+		{"last_price", "last_price"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := SnakeCase(tt.key); got != tt.want {
+				t.Errorf("SnakeCase(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	payload := pairPayload{
+		Symbol:       "BTCUSDT",
+		LastPrice:    95012.3,
+		Change24h:    -1.5,
+		LastCandle:   candlePayload{Time: 1735689600000, OpenPrice: 95000},
+		RecentTrades: []candlePayload{{Time: 1735689540000, OpenPrice: 94990.5}},
+		IndexWeights: map[string]float64{"BTCUSDT": 0.7, "ETHUSDT": 0.3},
+	}
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{StyleCamel, `{"symbol":"BTCUSDT","lastPrice":95012.3,"change24h":-1.5,` +
+			`"lastCandle":{"time":1735689600000,"openPrice":95000},` +
+			`"recentTrades":[{"time":1735689540000,"openPrice":94990.5}],` +
+			`"indexWeights":{"BTCUSDT":0.7,"ETHUSDT":0.3}}`},
+		{StyleSnake, `{"change24h":-1.5,"index_weights":{"BTCUSDT":0.7,"ETHUSDT":0.3},` +
+			`"last_candle":{"open_price":95000,"time":1735689600000},"last_price":95012.3,` +
+			`"recent_trades":[{"open_price":94990.5,"time":1735689540000}],"symbol":"BTCUSDT"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			v, err := Transform(payload, tt.style)
+			if err != nil {
+				t.Fatalf("Transform: %v", err)
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got  %s\nwant %s", data, tt.want)
+			}
+
+			// TransformJSON gives the same document from the camelCase encoding
+			camel, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+			transformed, err := TransformJSON(camel, tt.style)
+			if err != nil {
+				t.Fatalf("TransformJSON: %v", err)
+			}
+			if string(transformed) != tt.want {
+				t.Errorf("TransformJSON got  %s\nwant %s", transformed, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformJSONInvalid(t *testing.T) {
+	if _, err := TransformJSON([]byte(`{"lastPrice":`), StyleSnake); err == nil {
+		t.Error("got no error for truncated JSON")
+	}
+}
@@ -0,0 +1,74 @@
+package naming
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Number formats of JSON output.
+const (
+	NumbersDefault = "default" // As encoding/json writes them, with an exponent below 1e-6 or from 1e21.
+	NumbersPlain   = "plain"   // Always plain decimals, e.g. 0.00000012 instead of 1.2e-07.
+)
+
+// PlainNumbers rewrites the numbers of an encoded JSON document that use exponent notation
+// as plain decimals with the same value. Strings and other numbers are left untouched.
+func PlainNumbers(data []byte) []byte {
+	if !bytes.ContainsAny(data, "eE") {
+		return data
+	}
+
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '"':
+			end := stringEnd(data, i)
+			out = append(out, data[i:end]...)
+			i = end
+		case c == '-' || isDigit(c):
+			end := i + 1
+			for end < len(data) && isNumberByte(data[end]) {
+				end++
+			}
+			out = appendPlainNumber(out, data[i:end])
+			i = end
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+// stringEnd returns the index just past the JSON string starting at data[start].
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++ // Skip the escaped character, it may be a quote
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// appendPlainNumber appends a JSON number, rewritten without exponent if it has one.
+func appendPlainNumber(out, number []byte) []byte {
+	if !bytes.ContainsAny(number, "eE") {
+		return append(out, number...)
+	}
+	value, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return append(out, number...)
+	}
+	return strconv.AppendFloat(out, value, 'f', -1, 64)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNumberByte(c byte) bool {
+	return isDigit(c) || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
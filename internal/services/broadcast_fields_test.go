@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateBroadcastFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"all", []string{FieldSymbol, FieldLastPrice, FieldMarkPrice, FieldPriceChange, FieldLastCandle}, false},
+		{"unknown", []string{FieldSymbol, "volume"}, true},
+		{"case matters", []string{"LastPrice"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBroadcastFields(tt.fields)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnknownField) {
+				t.Errorf("got %v, want ErrUnknownField", err)
+			}
+		})
+	}
+}
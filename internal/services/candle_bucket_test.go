@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+func TestCurrentBucket(t *testing.T) {
+	for _, interval := range []time.Duration{time.Second, time.Minute, 5 * time.Minute, time.Hour} {
+		t.Run(interval.String(), func(t *testing.T) {
+			s := newTestService(t, func(cfg *config.Config) {
+				cfg.CandleInterval = interval
+				cfg.CandleIntervals = nil
+				cfg.CandleAlignment = config.CandleAlignmentUTC
+			})
+			addIdlePair(t, s, "TESTUSDT")
+
+			before := time.Now().UnixMilli()
+			bucket, err := s.CurrentBucket("TESTUSDT")
+			if err != nil {
+				t.Fatalf("CurrentBucket: %v", err)
+			}
+			after := time.Now().UnixMilli()
+
+			start, end := bucket["start"].(int64), bucket["end"].(int64)
+			if start%interval.Milliseconds() != 0 {
+				t.Errorf("start %d is not on a %s boundary", start, interval)
+			}
+			if end-start != interval.Milliseconds() {
+				t.Errorf("bucket spans %dms, want %s", end-start, interval)
+			}
+			if start > after || end <= before {
+				t.Errorf("bucket [%d,%d) doesn't contain the current time %d", start, end, before)
+			}
+			// Remaining time is truncated to whole milliseconds
+			if remaining := bucket["remainingMs"].(int64); remaining < end-after-1 || remaining > end-before {
+				t.Errorf("remaining %dms, want about %dms", remaining, end-before)
+			}
+		})
+	}
+}
+
+func TestCurrentBucketUnknownPair(t *testing.T) {
+	s := newTestService(t, nil)
+	if _, err := s.CurrentBucket("NOPEUSDT"); !errors.Is(err, ErrTradingPairNotFound) {
+		t.Errorf("got %v, want ErrTradingPairNotFound", err)
+	}
+}
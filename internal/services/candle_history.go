@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// ReplaceCandleData replaces the candle history of a running pair with candles, oldest first,
+// and continues the simulation from the close of the last one: that candle keeps running if
+// it is the current interval, otherwise a new candle opens at its close. The raw ticks are
+// cleared, and subscribers of the pair are sent a snapshot of the new history.
+func (s *DataService) ReplaceCandleData(
+	ctx context.Context,
+	symbol string,
+	candles []models.CandleData,
+) (*models.TradingPair, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := s.roundedTime(s.clock.Now())
+	if err := s.validateCandleHistory(candles, now); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.lockLivePair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pair.CandleData = append(make([]models.CandleData, 0, maxCandleCount), candles...)
+	pair.AllTimeHigh = models.PriceRecord{}
+	pair.AllTimeLow = models.PriceRecord{}
+	for _, candle := range pair.CandleData {
+		updateRecords(pair, candle.High, candle.Time)
+		updateRecords(pair, candle.Low, candle.Time)
+	}
+	pair.Ticks = models.NewTickRing(maxTickCount)
+
+	last := candles[len(candles)-1]
+	pair.LastCandle = last
+	if last.Time < now.UnixMilli() {
+		pair.LastCandle = s.openCandle(last.Close, last, now)
+	}
+	pair.LastPrice = last.Close
+	pair.MarkPrice = last.Close
+	pair.PriceChange = (pair.LastPrice/pair.CandleData[0].Open - 1) * percentMultiplier
+	pair.LastUpdate = time.Now()
+	pair.HistoryReplaced = true
+	pair.Mutex.Unlock()
+
+	s.logger.Info("Replaced candle history", "symbol", pair.Symbol, "count", len(candles),
+		"from", time.UnixMilli(candles[0].Time), "to", time.UnixMilli(last.Time))
+	s.sendSnapshots(pair)
+	return pair, nil
+}
+
+// validateCandleHistory checks that candles can stand in for a pair's history: at most
+// maxCandleCount of them, each with positive, consistent prices and on a candle boundary,
+// in strictly increasing time so no two cover the same interval, and none after the
+// current interval.
+func (s *DataService) validateCandleHistory(candles []models.CandleData, now time.Time) error {
+	if len(candles) == 0 || len(candles) > maxCandleCount {
+		return fmt.Errorf("%w: expected 1 to %d candles, got %d", ErrInvalidCandles, maxCandleCount, len(candles))
+	}
+
+	for i, candle := range candles {
+		switch {
+		case candle.Open <= 0 || candle.High <= 0 || candle.Low <= 0 || candle.Close <= 0:
+			return fmt.Errorf("%w: candle %d must have positive prices", ErrInvalidCandles, i)
+		case candle.High < max(candle.Open, candle.Close) || candle.Low > min(candle.Open, candle.Close):
+			return fmt.Errorf("%w: candle %d must have its open and close between low and high", ErrInvalidCandles, i)
+		case candle.Volume < 0:
+			return fmt.Errorf("%w: candle %d must not have a negative volume", ErrInvalidCandles, i)
+		case !s.roundedTime(time.UnixMilli(candle.Time)).Equal(time.UnixMilli(candle.Time)):
+			return fmt.Errorf("%w: candle %d time is not on a %s candle boundary", ErrInvalidCandles, i, s.candleInterval)
+		case i > 0 && candle.Time <= candles[i-1].Time:
+			return fmt.Errorf("%w: candle %d time must be after the time of candle %d", ErrInvalidCandles, i, i-1)
+		case candle.Time > now.UnixMilli():
+			return fmt.Errorf("%w: candle %d is after the current interval", ErrInvalidCandles, i)
+		}
+	}
+	return nil
+}
+
+// sendSnapshots sends every subscriber of the pair its whole candle history, in the
+// subscriber's time format.
+func (s *DataService) sendSnapshots(pair *models.TradingPair) {
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+
+	for sub := range pair.Subscribers {
+		candles := make([]models.FormattedCandle, len(pair.CandleData))
+		for i, candle := range pair.CandleData {
+			candles[i] = candle.WithTimeFormat(sub.TimeFormat())
+		}
+		if !sub.SendSnapshot(pair.Symbol, candles) {
+			s.logger.Warn("Snapshot not delivered, send queue full", "conn", sub.ID(), "symbol", pair.Symbol)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// CandleHook is notified when a candle closes. It is called from the pair's simulation
+// with the pair locked, so implementations must return quickly and never call back into
+// the DataService.
+type CandleHook interface {
+	CandleFinalized(symbol string, candle models.CandleData)
+}
+
+// NopCandleHook ignores finalized candles, it is the default hook.
+type NopCandleHook struct{}
+
+// CandleFinalized does nothing.
+func (NopCandleHook) CandleFinalized(string, models.CandleData) {}
+
+// SetCandleHook sets the hook notified of finalized candles. It must be called before the
+// trading pairs are initialized.
+func (s *DataService) SetCandleHook(hook CandleHook) {
+	s.candleHook = hook
+}
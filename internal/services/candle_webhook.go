@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// Retry backoff of webhook deliveries.
+const (
+	webhookBackoffBase = 500 * time.Millisecond // Delay before the first retry, doubled after each one.
+	webhookBackoffMax  = 30 * time.Second       // Longest delay between two attempts.
+)
+
+// candleEvent is the body POSTed to the webhook for a finalized candle.
+type candleEvent struct {
+	Symbol string            `json:"symbol"`
+	Candle models.CandleData `json:"candle"`
+}
+
+// WebhookCandleHook POSTs finalized candles to a URL. Candles are queued and delivered in
+// order by a single worker, so a slow endpoint never holds up the simulation; when the
+// queue is full new candles are dropped.
+type WebhookCandleHook struct {
+	url        string
+	maxRetries int
+	client     *http.Client
+	queue      chan candleEvent
+	logger     *slog.Logger
+}
+
+// NewWebhookCandleHook creates a webhook hook, Run must be started to deliver candles.
+func NewWebhookCandleHook(logger *slog.Logger, cfg config.CandleWebhookConfig) *WebhookCandleHook {
+	return &WebhookCandleHook{
+		url:        cfg.URL,
+		maxRetries: cfg.MaxRetries,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		queue:      make(chan candleEvent, cfg.QueueSize),
+		logger:     logger,
+	}
+}
+
+// CandleFinalized queues the candle for delivery.
+func (h *WebhookCandleHook) CandleFinalized(symbol string, candle models.CandleData) {
+	select {
+	case h.queue <- candleEvent{Symbol: symbol, Candle: candle}:
+	default:
+		h.logger.Warn("Candle webhook queue full, dropping candle", "symbol", symbol, "time", candle.Time)
+	}
+}
+
+// Run delivers queued candles until ctx is cancelled.
+func (h *WebhookCandleHook) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-h.queue:
+			h.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver POSTs one event, retrying with exponential backoff up to maxRetries times.
+func (h *WebhookCandleHook) deliver(ctx context.Context, event candleEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Error encoding candle event", "symbol", event.Symbol, "error", err)
+		return
+	}
+
+	backoff := webhookBackoffBase
+	for attempt := 0; ; attempt++ {
+		err = h.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempt >= h.maxRetries {
+			h.logger.Error("Candle webhook delivery failed, giving up",
+				"symbol", event.Symbol, "time", event.Candle.Time, "attempts", attempt+1, "error", err)
+			return
+		}
+
+		h.logger.Warn("Candle webhook delivery failed, retrying",
+			"symbol", event.Symbol, "attempt", attempt+1, "retryIn", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, webhookBackoffMax)
+	}
+}
+
+// post sends the body once, any status outside 2xx counts as a failure.
+func (h *WebhookCandleHook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// recordingHook keeps the candles it is notified of.
+type recordingHook struct {
+	mu      sync.Mutex
+	candles []models.CandleData
+}
+
+func (h *recordingHook) CandleFinalized(_ string, candle models.CandleData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.candles = append(h.candles, candle)
+}
+
+func TestCandleFinalizedHook(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int // Candle intervals from the last history candle.
+		wantCalls int
+	}{
+		{"new candle", 1, 1},
+		{"replaces last history candle", 0, 1},
+		{"older than history", -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, nil)
+			hook := &recordingHook{}
+			s.SetCandleHook(hook)
+			pair := addIdlePair(t, s, "TESTUSDT")
+
+			last := pair.CandleData[len(pair.CandleData)-1]
+			current := last
+			current.Time += int64(tt.offset) * s.candleInterval.Milliseconds()
+			current.Close = last.Close + 1
+			s.createNewCandle(pair, &current, time.UnixMilli(current.Time).Add(s.candleInterval))
+
+			if len(hook.candles) != tt.wantCalls {
+				t.Fatalf("hook called %d times, want %d", len(hook.candles), tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && hook.candles[0].Close != last.Close+1 {
+				t.Errorf("hook got close %v, want the finalized candle's %v", hook.candles[0].Close, last.Close+1)
+			}
+		})
+	}
+}
+
+func TestWebhookCandleHookDelivers(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32 // Requests answered with 500 before the endpoint succeeds.
+		maxRetries   int
+		wantRequests int32
+	}{
+		{"first attempt", 0, 2, 1},
+		{"after a retry", 1, 2, 2},
+		{"gives up", 5, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			events := make(chan candleEvent, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("got %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+				}
+				if requests.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				var event candleEvent
+				if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+					t.Errorf("decoding event: %v", err)
+				}
+				events <- event
+			}))
+			defer server.Close()
+
+			hook := NewWebhookCandleHook(slog.New(slog.NewTextHandler(io.Discard, nil)), config.CandleWebhookConfig{
+				URL: server.URL, QueueSize: 1, Timeout: time.Second, MaxRetries: tt.maxRetries,
+			})
+			candle := models.CandleData{Time: 1700000000000, Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 10}
+			hook.CandleFinalized("BTCUSDT", candle)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				hook.deliver(ctx, <-hook.queue)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("delivery did not finish")
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tt.wantRequests)
+			}
+
+			select {
+			case event := <-events:
+				if event.Symbol != "BTCUSDT" || event.Candle != candle {
+					t.Errorf("got %+v, want BTCUSDT %+v", event, candle)
+				}
+			default:
+				if tt.failures < tt.wantRequests {
+					t.Error("endpoint did not receive the candle")
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookCandleHookQueueFull(t *testing.T) {
+	hook := NewWebhookCandleHook(slog.New(slog.NewTextHandler(io.Discard, nil)), config.CandleWebhookConfig{
+		URL: "http://127.0.0.1", QueueSize: 1, Timeout: time.Second,
+	})
+	hook.CandleFinalized("BTCUSDT", models.CandleData{Time: 1})
+	hook.CandleFinalized("BTCUSDT", models.CandleData{Time: 2})
+
+	if got := len(hook.queue); got != 1 {
+		t.Fatalf("queue holds %d candles, want 1", got)
+	}
+	if event := <-hook.queue; event.Candle.Time != 1 {
+		t.Errorf("queued candle %d, want the first one", event.Candle.Time)
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestServiceCallsStopOnCancelledContext(t *testing.T) {
+	s := newTestService(t, nil)
+	addIdlePair(t, s, "TESTUSDT")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"GetCandleData", func() error { _, err := s.GetCandleData(ctx, "TESTUSDT"); return err }},
+		{"GetTicks", func() error { _, err := s.GetTicks(ctx, "TESTUSDT", 10); return err }},
+		{"PriceHistory", func() error { _, err := s.PriceHistory(ctx, "TESTUSDT", 0, 0, 10); return err }},
+		{"VolumeAnomalies", func() error { _, err := s.VolumeAnomalies(ctx, "TESTUSDT", 20, 3); return err }},
+		{"Renko", func() error { _, err := s.Renko(ctx, "TESTUSDT", s.candleInterval, 1, 0); return err }},
+		{"Stats", func() error { _, err := s.Stats(ctx, 0); return err }},
+		{"IndexPrice", func() error { _, err := s.IndexPrice(ctx, "TESTUSDT"); return err }},
+		{"SetVolatility", func() error { _, _, err := s.SetVolatility(ctx, "TESTUSDT", 2); return err }},
+		{"AddTradingPair", func() error { _, _, err := s.AddTradingPair(ctx, "NEWUSDT", ptr(1.0), nil, false); return err }},
+		{"RemoveTradingPair", func() error { return s.RemoveTradingPair(ctx, "TESTUSDT") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want context.Canceled", err)
+			}
+		})
+	}
+
+	// Nothing was changed by the cancelled calls
+	if !s.HasPair("TESTUSDT") || s.HasPair("NEWUSDT") {
+		t.Error("cancelled calls changed the pairs")
+	}
+}
@@ -1,15 +1,19 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
+	"fmt"
 	"log/slog"
+	"maps"
 	"math"
 	"math/big"
+	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
-
+	"github.com/sand/crypto-trading-app/backend/internal/config"
 	"github.com/sand/crypto-trading-app/backend/internal/models"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
 )
 
 // Constants to avoid magic numbers.
@@ -18,17 +22,11 @@ const (
 	maxRandomBits      = 53  // Maximum bits for random number generation (JavaScript's Number.MAX_SAFE_INTEGER).
 	defaultRandomValue = 0.5 // Default value when random generation fails.
 
-	// Trading pair initial prices.
-	btcInitialPrice = 95000.0
-	ethInitialPrice = 3500.0
-	solInitialPrice = 180.0
-	bnbInitialPrice = 600.0
-	xrpInitialPrice = 0.55
-
 	// Candle data constants.
-	maxCandleCount       = 288  // 288 candles of 5 minutes each = 24 hours.
+	maxCandleCount       = 288  // Candles kept per pair, 288 candles of 5 minutes each = 24 hours.
 	priceUpdateInterval  = 500  // 500 milliseconds between price updates.
-	timestampMultiplier  = 1000 // Convert seconds to milliseconds.
+	minTicksPerCandle    = 5    // Short candles tick faster, so each still gets a few price updates.
+	maxTickCount         = 1200 // Raw ticks kept per pair, 10 minutes at 500ms.
 	defaultVolume        = 50   // Default trading volume.
 	maxVolumeVariation   = 150  // Maximum volume variation for historical candles.
 	smallVolumeVariation = 20   // Small volume variation for new candles.
@@ -44,39 +42,112 @@ const (
 	lowPriceVariationBase    = 0.995 // Base multiplier for low price.
 	lowPriceVariationRange   = 0.005 // Range of variation for low price (0.5%).
 
-	// Time constants.
-	minutesPerCandle     = 5  // Each candle represents 5 minutes.
-	hoursPerDay          = 24 // Hours in a day for historical data.
-	candleTickerInterval = 1  // 1 second interval for candle ticker.
-	demoIntervalSeconds  = 10 // 10-second interval for demonstration.
-
 	// Simulation constants.
 	realtimePriceVariationMax = 0.004 // Maximum price variation for real-time updates (0.4%).
 	realtimePriceVariationMin = 0.002 // Minimum price variation for real-time updates (0.2%).
 	percentMultiplier         = 100   // Multiplier to convert decimal to percentage.
+
+	// markPriceSmoothing is the EMA weight of the newest price in the mark price.
+	// At 500ms ticks it roughly averages the last 10 seconds of prices.
+	markPriceSmoothing = 0.1
+
+	// DefaultVolatility is the multiplier applied to real-time price variation of a new pair.
+	DefaultVolatility = 1.0
+	// MaxVolatility caps a volatility set at runtime, ten times the default already swings
+	// a pair by several percent per tick.
+	MaxVolatility = 10.0
 )
 
+// Broadcast payload field names.
+const (
+	FieldSymbol      = "symbol"
+	FieldLastPrice   = "lastPrice"
+	FieldMarkPrice   = "markPrice"
+	FieldPriceChange = "priceChange"
+	FieldLastCandle  = "lastCandle"
+)
+
+// broadcastFields is the set of fields a subscriber may select.
+func broadcastFields() map[string]bool {
+	return map[string]bool{
+		FieldSymbol:      true,
+		FieldLastPrice:   true,
+		FieldMarkPrice:   true,
+		FieldPriceChange: true,
+		FieldLastCandle:  true,
+	}
+}
+
+// ValidateBroadcastFields checks that every requested field exists in the broadcast payload.
+func ValidateBroadcastFields(fields []string) error {
+	known := broadcastFields()
+	for _, field := range fields {
+		if !known[field] {
+			return fmt.Errorf("%w: %q", ErrUnknownField, field)
+		}
+	}
+	return nil
+}
+
 type DataService struct {
-	TradingPairs map[string]*models.TradingPair
-	logger       *slog.Logger
+	pairs          map[string]*models.TradingPair
+	pairsMu        sync.RWMutex // Guards the pairs map, pairs can be added at runtime.
+	candleInterval time.Duration
+	intervals      []time.Duration // Intervals candles can be requested in, base interval first.
+	historyBudget  time.Duration   // Time generating one pair's history may take, 0 for no limit.
+	initialPairs   []config.PairConfig
+	maxPairs       int
+	aliases        map[string]string // Alternative symbols accepted in requests, fixed at startup.
+	regime         config.RegimeConfig
+	priceModel     PriceModel
+	volumeProfile  []float64      // Volume weight per UTC hour.
+	volumeMode     string         // Per-candle or cumulative session volume.
+	location       *time.Location // Candle boundaries are aligned to midnight here.
+	clock          simClock       // Simulated time, candle times and boundaries follow it.
+	candleHook     CandleHook
+	logger         *slog.Logger
 }
 
-func NewDataService(logger *slog.Logger) *DataService {
-	return &DataService{
-		TradingPairs: make(map[string]*models.TradingPair),
-		logger:       logger,
+func NewDataService(logger *slog.Logger, cfg *config.Config) (*DataService, error) {
+	priceModel, err := newPriceModel(cfg, priceTickFor(cfg.CandleInterval), logger)
+	if err != nil {
+		return nil, err
 	}
+
+	return &DataService{
+		pairs:          make(map[string]*models.TradingPair),
+		candleInterval: cfg.CandleInterval,
+		intervals:      append([]time.Duration{cfg.CandleInterval}, cfg.CandleIntervals...),
+		historyBudget:  cfg.HistoryBudget,
+		initialPairs:   cfg.Pairs,
+		maxPairs:       cfg.MaxPairs,
+		aliases:        cfg.SymbolAliases,
+		regime:         cfg.Regime,
+		priceModel:     priceModel,
+		volumeProfile:  cfg.VolumeProfile,
+		volumeMode:     cfg.VolumeMode,
+		location:       candleLocation(cfg.CandleAlignment),
+		clock:          newSimClock(cfg.SimulationSpeed, cfg.SimulationClock == config.SimulationClockMonotonic),
+		candleHook:     NopCandleHook{},
+		logger:         logger,
+	}, nil
 }
 
 // NewTradingPair creates a new trading pair.
 func NewTradingPair(symbol string, initialPrice float64) *models.TradingPair {
 	return &models.TradingPair{
-		Symbol:      symbol,
-		LastPrice:   initialPrice,
-		PriceChange: 0,
-		CandleData:  make([]models.CandleData, 0),
-		Subscribers: make(map[*websocket.Conn]bool),
-		StopChan:    make(chan struct{}),
+		Symbol:           symbol,
+		InitialPrice:     initialPrice,
+		Volatility:       DefaultVolatility,
+		Regime:           RegimeCalm,
+		LastPrice:        initialPrice,
+		MarkPrice:        initialPrice,
+		PriceChange:      0,
+		CandleData:       make([]models.CandleData, 0),
+		Ticks:            models.NewTickRing(maxTickCount),
+		Subscribers:      make(map[*websocket.Subscriber]bool),
+		KlineSubscribers: make(map[time.Duration]map[*websocket.Subscriber]bool),
+		StopChan:         make(chan struct{}),
 	}
 }
 
@@ -94,47 +165,283 @@ func secureFloat64(logger *slog.Logger) float64 {
 	return float64(n.Int64()) / float64(maxVal.Int64())
 }
 
-// InitializeTradingPairs initializes trading pairs with initial data.
+// InitializeTradingPairs initializes the configured trading pairs with initial data.
 func (s *DataService) InitializeTradingPairs() {
-	// Create trading pairs with initial prices
-	s.TradingPairs["BTCUSDT"] = NewTradingPair("BTCUSDT", btcInitialPrice)
-	s.TradingPairs["ETHUSDT"] = NewTradingPair("ETHUSDT", ethInitialPrice)
-	s.TradingPairs["SOLUSDT"] = NewTradingPair("SOLUSDT", solInitialPrice)
-	s.TradingPairs["BNBUSDT"] = NewTradingPair("BNBUSDT", bnbInitialPrice)
-	s.TradingPairs["XRPUSDT"] = NewTradingPair("XRPUSDT", xrpInitialPrice)
-
-	// Generate initial candle data
-	for _, pair := range s.TradingPairs {
-		s.GenerateInitialCandleData(pair)
-		// Start simulation in a separate goroutine
-		go s.SimulateTradingData(pair)
+	for _, pair := range s.initialPairs {
+		s.startPair(NewTradingPair(pair.Symbol, pair.InitialPrice))
 	}
 }
 
-// GenerateInitialCandleData generates initial candle data for a trading pair.
+// startPair generates history for a pair, registers it and starts its simulation.
+func (s *DataService) startPair(pair *models.TradingPair) {
+	s.GenerateInitialCandleData(pair)
+
+	s.pairsMu.Lock()
+	s.pairs[pair.Symbol] = pair
+	s.pairsMu.Unlock()
+
+	// Start simulation in a separate goroutine
+	go s.SimulateTradingData(pair)
+}
+
+// getPair looks up a trading pair by symbol.
+func (s *DataService) getPair(symbol string) (*models.TradingPair, error) {
+	s.pairsMu.RLock()
+	defer s.pairsMu.RUnlock()
+
+	pair, ok := s.pairs[s.resolveSymbol(symbol)]
+	if !ok {
+		return nil, ErrTradingPairNotFound
+	}
+	return pair, nil
+}
+
+// lockLivePair looks up a trading pair and returns it write-locked. A pair removed between
+// the lookup and the lock is looked up again, so a subscriber never attaches to a stopped
+// simulation but to the pair re-added under the symbol, if any.
+func (s *DataService) lockLivePair(symbol string) (*models.TradingPair, error) {
+	for {
+		pair, err := s.getPair(symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		pair.Mutex.Lock()
+		if !pair.Removed {
+			return pair, nil
+		}
+		pair.Mutex.Unlock()
+	}
+}
+
+// resolveSymbol maps an alias to the symbol of its pair, other symbols are returned as is.
+func (s *DataService) resolveSymbol(symbol string) string {
+	if canonical, ok := s.aliases[symbol]; ok {
+		return canonical
+	}
+	return symbol
+}
+
+// HasPair reports whether a trading pair exists under the symbol or an alias of it.
+func (s *DataService) HasPair(symbol string) bool {
+	_, err := s.getPair(symbol)
+	return err == nil
+}
+
+// Pairs returns a snapshot of all trading pairs.
+func (s *DataService) Pairs() []*models.TradingPair {
+	s.pairsMu.RLock()
+	defer s.pairsMu.RUnlock()
+
+	pairs := make([]*models.TradingPair, 0, len(s.pairs))
+	for _, pair := range s.pairs {
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// AddTradingPair creates a new trading pair and starts simulating it. If the pair already
+// exists and upsert is set, its initial price and volatility are updated in place instead
+// (nil values are left unchanged); otherwise ErrTradingPairExists is returned.
+// The returned flag reports whether a new pair was created.
+func (s *DataService) AddTradingPair(
+	ctx context.Context,
+	symbol string,
+	initialPrice, volatility *float64,
+	upsert bool,
+) (*models.TradingPair, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	// The history of a new pair is generated before taking the registry lock, lookups of
+	// every other pair must not wait for it
+	var fresh *models.TradingPair
+	if initialPrice != nil && !s.registered(symbol) {
+		fresh = s.newPair(symbol, *initialPrice, volatility)
+	}
+
+	// Hold the registry lock across check and insert so concurrent adds can't both create the pair
+	s.pairsMu.Lock()
+	defer s.pairsMu.Unlock()
+
+	if _, ok := s.aliases[symbol]; ok {
+		return nil, false, ErrSymbolIsAlias
+	}
+
+	if pair, ok := s.pairs[symbol]; ok {
+		if !upsert {
+			return nil, false, ErrTradingPairExists
+		}
+
+		// The simulation reads these fields under the pair lock on every tick, a new initial
+		// price moves the live price so the next tick walks from it
+		pair.Mutex.Lock()
+		if initialPrice != nil {
+			pair.InitialPrice = *initialPrice
+			pair.LastPrice = *initialPrice
+			pair.MarkPrice = *initialPrice
+		}
+		if volatility != nil {
+			pair.Volatility = *volatility
+		}
+		pair.Mutex.Unlock()
+
+		s.logger.Info("Updated trading pair", "symbol", symbol)
+		return pair, false, nil
+	}
+
+	if initialPrice == nil {
+		return nil, false, ErrInitialPriceRequired
+	}
+	if len(s.pairs) >= s.maxPairs {
+		return nil, false, ErrPairLimitReached
+	}
+
+	if fresh == nil {
+		// The pair was removed after the check above, rare enough to generate under the lock
+		fresh = s.newPair(symbol, *initialPrice, volatility)
+	}
+	s.pairs[symbol] = fresh
+	go s.SimulateTradingData(fresh)
+
+	s.logger.Info("Added trading pair", "symbol", symbol, "initialPrice", *initialPrice)
+	return fresh, true, nil
+}
+
+// registered reports whether a pair is registered under exactly this symbol.
+func (s *DataService) registered(symbol string) bool {
+	s.pairsMu.RLock()
+	defer s.pairsMu.RUnlock()
+	_, ok := s.pairs[symbol]
+	return ok
+}
+
+// newPair creates a pair with its generated history, not yet registered or simulated.
+func (s *DataService) newPair(symbol string, initialPrice float64, volatility *float64) *models.TradingPair {
+	pair := NewTradingPair(symbol, initialPrice)
+	if volatility != nil {
+		pair.Volatility = *volatility
+	}
+	s.GenerateInitialCandleData(pair)
+	return pair
+}
+
+// SetVolatility changes the volatility of a running pair, taking effect from its next tick.
+// It returns the pair and the volatility it had before.
+func (s *DataService) SetVolatility(
+	ctx context.Context,
+	symbol string,
+	volatility float64,
+) (*models.TradingPair, float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// The simulation reads the volatility under the pair lock on every tick
+	pair, err := s.lockLivePair(symbol)
+	if err != nil {
+		return nil, 0, err
+	}
+	previous := pair.Volatility
+	pair.Volatility = volatility
+	pair.Mutex.Unlock()
+
+	s.logger.Info("Updated volatility", "symbol", pair.Symbol, "volatility", volatility, "previous", previous)
+	return pair, previous, nil
+}
+
+// RemoveTradingPair stops simulating a pair and deletes it. Its subscribers are unsubscribed
+// and told so; connections that subscribed to nothing else are closed.
+func (s *DataService) RemoveTradingPair(ctx context.Context, symbol string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.pairsMu.Lock()
+	symbol = s.resolveSymbol(symbol)
+	pair, ok := s.pairs[symbol]
+	if !ok {
+		s.pairsMu.Unlock()
+		return ErrTradingPairNotFound
+	}
+	delete(s.pairs, symbol)
+	s.pairsMu.Unlock()
+
+	close(pair.StopChan)
+
+	// Subscribers still attaching to the pair see it removed and look the symbol up again
+	pair.Mutex.Lock()
+	pair.Removed = true
+	subscribers := pair.Subscribers
+	for _, klineSubscribers := range pair.KlineSubscribers {
+		maps.Copy(subscribers, klineSubscribers)
+	}
+	pair.Subscribers = make(map[*websocket.Subscriber]bool)
+	pair.KlineSubscribers = make(map[time.Duration]map[*websocket.Subscriber]bool)
+	pair.Mutex.Unlock()
+
+	for sub := range subscribers {
+		sub.SymbolRemoved(pair.Symbol)
+	}
+
+	s.logger.Info("Removed trading pair", "symbol", pair.Symbol, "subscribers", len(subscribers))
+	return nil
+}
+
+// GenerateInitialCandleData generates initial candle data for a trading pair. The history is
+// generated without holding the pair lock and swapped in at once, so readers of the pair
+// never wait for generation and see either the old or the new history.
 func (s *DataService) GenerateInitialCandleData(pair *models.TradingPair) {
-	now := time.Now()
-	// Round to the beginning of the current 5-minute interval
-	currentInterval := time.Date(
-		now.Year(), now.Month(), now.Day(),
-		now.Hour(), now.Minute()-now.Minute()%minutesPerCandle, 0, 0,
-		now.Location(),
-	)
-	startTime := currentInterval.Add(-hoursPerDay * time.Hour) // 24 hours ago
-
-	// Create slice with required capacity for optimization
+	pair.Mutex.RLock()
+	lastPrice := pair.LastPrice
+	pair.Mutex.RUnlock()
+
+	started := time.Now()
+	candles := s.generateHistory(lastPrice)
+	if len(candles) < maxCandleCount {
+		s.logger.Warn("Candle generation budget exhausted, history shortened", "symbol", pair.Symbol,
+			"count", len(candles), "budget", s.historyBudget)
+	}
+
 	pair.Mutex.Lock()
 	defer pair.Mutex.Unlock()
 
-	pair.CandleData = make([]models.CandleData, 0, maxCandleCount)
+	pair.CandleData = candles
 
-	// Base price for the first candle
-	basePrice := pair.LastPrice * basePercentage
+	// The generated history is all the pair has seen so far, the records start from it
+	pair.AllTimeHigh = models.PriceRecord{}
+	pair.AllTimeLow = models.PriceRecord{}
+	for _, candle := range pair.CandleData {
+		updateRecords(pair, candle.High, candle.Time)
+		updateRecords(pair, candle.Low, candle.Time)
+	}
 
-	// Generate candles for the last 24 hours (5-minute candles)
-	for i := range make([]int, maxCandleCount) { // 288 candles of 5 minutes each = 24 hours
-		candleTime := startTime.Add(time.Duration(i) * minutesPerCandle * time.Minute)
+	// Set last candle
+	if len(pair.CandleData) > 0 {
+		pair.LastCandle = pair.CandleData[len(pair.CandleData)-1]
+		pair.LastPrice = pair.LastCandle.Close
+		pair.MarkPrice = pair.LastPrice
+	}
+	pair.LastUpdate = time.Now()
 
+	s.logger.Info("Generated candles", "symbol", pair.Symbol, "count", len(pair.CandleData),
+		"duration", time.Since(started))
+}
+
+// generateHistory generates up to maxCandleCount candles walking from basePercentage of
+// lastPrice, fewer if the history budget runs out. Times and volumes are assigned once the
+// prices are drawn, so a history cut short still ends at the current, still open interval
+// that the simulation continues.
+func (s *DataService) generateHistory(lastPrice float64) []models.CandleData {
+	started := time.Now()
+	candles := make([]models.CandleData, 0, maxCandleCount)
+	volumeDraws := make([]float64, 0, maxCandleCount)
+
+	// Base price for the first candle
+	basePrice := lastPrice * basePercentage
+
+	for len(candles) < maxCandleCount {
 		// Create a small price change for each candle
 		priceChange := basePrice * (secureFloat64(s.logger)*maxPriceVariationPercent -
 			minPriceVariationPercent) // -2% to +2%
@@ -149,27 +456,31 @@ func (s *DataService) GenerateInitialCandleData(pair *models.TradingPair) {
 			secureFloat64(s.logger)*highPriceVariationRange)
 		low := math.Min(openPrice, closePrice) * (lowPriceVariationBase -
 			secureFloat64(s.logger)*lowPriceVariationRange)
-		volume := defaultVolume + secureFloat64(s.logger)*maxVolumeVariation
-
-		candle := models.CandleData{
-			Time:   candleTime.Unix() * timestampMultiplier, // milliseconds
-			Open:   openPrice,
-			High:   high,
-			Low:    low,
-			Close:  closePrice,
-			Volume: volume,
-		}
 
-		pair.CandleData = append(pair.CandleData, candle)
-	}
+		candles = append(candles, models.CandleData{
+			Open:  openPrice,
+			High:  high,
+			Low:   low,
+			Close: closePrice,
+		})
+		volumeDraws = append(volumeDraws, secureFloat64(s.logger))
 
-	// Set last candle
-	if len(pair.CandleData) > 0 {
-		pair.LastCandle = pair.CandleData[len(pair.CandleData)-1]
-		pair.LastPrice = pair.LastCandle.Close
+		if s.historyBudget > 0 && time.Since(started) > s.historyBudget {
+			break
+		}
 	}
 
-	s.logger.Info("Generated candles", "symbol", pair.Symbol, "count", len(pair.CandleData))
+	currentInterval := s.roundedTime(s.clock.Now())
+	startTime := currentInterval.Add(-time.Duration(len(candles)-1) * s.candleInterval)
+	for i := range candles {
+		candleTime := startTime.Add(time.Duration(i) * s.candleInterval)
+		candles[i].Time = candleTime.UnixMilli()
+		candles[i].Volume = (defaultVolume + volumeDraws[i]*maxVolumeVariation) * s.volumeWeight(candleTime)
+		if i > 0 {
+			candles[i].Volume += s.carriedVolume(candles[i-1], candleTime)
+		}
+	}
+	return candles
 }
 
 // updatePriceAndCandle updates the current price and candle data.
@@ -179,11 +490,14 @@ func (s *DataService) updatePriceAndCandle(
 ) {
 	pair.Mutex.Lock()
 	defer pair.Mutex.Unlock()
+	resumeReplacedHistory(pair, currentCandle)
 
-	// -0.2% to +0.2%
-	priceChange := pair.LastPrice * (secureFloat64(s.logger)*realtimePriceVariationMax -
-		realtimePriceVariationMin)
+	// Relative move from the price model, scaled by the pair's volatility and current regime
+	regimeMultiplier := s.stepRegime(pair)
+	priceChange := pair.LastPrice * s.priceModel.NextReturn(pair.Symbol) * pair.Volatility * regimeMultiplier
 	pair.LastPrice += priceChange
+	// The mark price follows the last price through an EMA, so one wick barely moves it
+	pair.MarkPrice += markPriceSmoothing * (pair.LastPrice - pair.MarkPrice)
 
 	// Update current candle
 	if pair.LastPrice > currentCandle.High {
@@ -193,10 +507,15 @@ func (s *DataService) updatePriceAndCandle(
 		currentCandle.Low = pair.LastPrice
 	}
 	currentCandle.Close = pair.LastPrice
-	currentCandle.Volume += secureFloat64(s.logger) * smallVolumeVariation // Small increase in volume
+	now := s.clock.Now().UnixMilli()
+	updateRecords(pair, pair.LastPrice, now)
+	pair.Ticks.Push(models.Tick{Time: now, Price: pair.LastPrice})
+	// Small increase in volume, larger during busy hours
+	currentCandle.Volume += secureFloat64(s.logger) * smallVolumeVariation * s.volumeWeight(s.clock.Now())
 
 	// Update last candle
 	pair.LastCandle = *currentCandle
+	pair.LastUpdate = time.Now()
 
 	if len(pair.CandleData) > 0 {
 		// Calculate % change from first candle
@@ -205,6 +524,18 @@ func (s *DataService) updatePriceAndCandle(
 	}
 }
 
+// updateRecords moves the all-time high or low of the pair to price when price breaks it.
+// Records are tracked incrementally because trimmed candles can't be looked at again.
+// The caller must hold the pair's lock.
+func updateRecords(pair *models.TradingPair, price float64, t int64) {
+	if pair.AllTimeHigh.Time == 0 || price > pair.AllTimeHigh.Price {
+		pair.AllTimeHigh = models.PriceRecord{Price: price, Time: t}
+	}
+	if pair.AllTimeLow.Time == 0 || price < pair.AllTimeLow.Price {
+		pair.AllTimeLow = models.PriceRecord{Price: price, Time: t}
+	}
+}
+
 // createNewCandle creates a new candle and adds the current one to history.
 func (s *DataService) createNewCandle(
 	pair *models.TradingPair,
@@ -213,41 +544,106 @@ func (s *DataService) createNewCandle(
 ) {
 	pair.Mutex.Lock()
 	defer pair.Mutex.Unlock()
-
-	// Save current candle to history
-	if len(pair.CandleData) == 0 || currentCandle.Time > pair.CandleData[len(pair.CandleData)-1].Time {
+	resumeReplacedHistory(pair, currentCandle)
+
+	// Save current candle to history, the generated history already holds a stale copy of its first candle
+	last := len(pair.CandleData) - 1
+	if last >= 0 && currentCandle.Time == pair.CandleData[last].Time {
+		pair.CandleData[last] = *currentCandle
+		s.candleHook.CandleFinalized(pair.Symbol, *currentCandle)
+	} else if last < 0 || currentCandle.Time > pair.CandleData[last].Time {
 		pair.CandleData = append(pair.CandleData, *currentCandle)
 		// Keep only last 288 candles
 		if len(pair.CandleData) > maxCandleCount {
 			pair.CandleData = pair.CandleData[len(pair.CandleData)-maxCandleCount:]
 		}
 		s.logger.Info("Created new candle for pair", "symbol", pair.Symbol,
-			"time", time.Unix(currentCandle.Time/timestampMultiplier, 0))
+			"time", time.UnixMilli(currentCandle.Time))
+		s.candleHook.CandleFinalized(pair.Symbol, *currentCandle)
 	}
 
 	// Create a new current candle
-	*currentCandle = models.CandleData{
-		Time:   roundedTime.Unix() * timestampMultiplier,
-		Open:   pair.LastPrice,
-		High:   pair.LastPrice,
-		Low:    pair.LastPrice,
-		Close:  pair.LastPrice,
-		Volume: defaultVolume + secureFloat64(s.logger)*smallVolumeVariation,
-	}
+	*currentCandle = s.openCandle(pair.LastPrice, *currentCandle, roundedTime)
 
 	// Update last candle
 	pair.LastCandle = *currentCandle
+	pair.LastUpdate = time.Now()
+}
+
+// openCandle returns a new candle starting at t at price, following prev.
+func (s *DataService) openCandle(price float64, prev models.CandleData, t time.Time) models.CandleData {
+	volume := (defaultVolume + secureFloat64(s.logger)*smallVolumeVariation) * s.volumeWeight(t)
+	return models.CandleData{
+		Time:   t.UnixMilli(),
+		Open:   price,
+		High:   price,
+		Low:    price,
+		Close:  price,
+		Volume: volume + s.carriedVolume(prev, t),
+	}
+}
+
+// resumeReplacedHistory moves the simulation's current candle to the one the pair's
+// replaced history continues with. The caller must hold the pair's write lock.
+func resumeReplacedHistory(pair *models.TradingPair, currentCandle *models.CandleData) {
+	if !pair.HistoryReplaced {
+		return
+	}
+	pair.HistoryReplaced = false
+	*currentCandle = pair.LastCandle
 }
 
-// getRoundedTime returns a time rounded to the demonstration interval.
-func getRoundedTime() time.Time {
-	now := time.Now()
-	// Use a 10-second interval for demonstration
-	return time.Date(
-		now.Year(), now.Month(), now.Day(),
-		now.Hour(), now.Minute(), now.Second()/demoIntervalSeconds*demoIntervalSeconds, 0,
-		now.Location(),
-	)
+// volumeWeight returns the intraday volume weight for the UTC hour of t.
+func (s *DataService) volumeWeight(t time.Time) float64 {
+	return s.volumeProfile[t.UTC().Hour()]
+}
+
+// candleLocation returns the time zone of the given candle alignment.
+func candleLocation(alignment string) *time.Location {
+	if alignment == config.CandleAlignmentLocal {
+		return time.Local
+	}
+	return time.UTC
+}
+
+// roundedTime returns the start of the candle interval containing t.
+// Intervals are counted from midnight so boundaries fall on round clock times.
+func (s *DataService) roundedTime(t time.Time) time.Time {
+	return s.alignTime(t, s.candleInterval)
+}
+
+// priceTick returns the simulated time between price updates.
+func (s *DataService) priceTick() time.Duration {
+	return priceTickFor(s.candleInterval)
+}
+
+// priceTickFor returns the simulated time between price updates with candles of interval.
+func priceTickFor(candleInterval time.Duration) time.Duration {
+	return min(time.Duration(priceUpdateInterval)*time.Millisecond, candleInterval/minTicksPerCandle)
+}
+
+// untilNextCandle returns the time left until the candle interval containing t ends.
+func (s *DataService) untilNextCandle(t time.Time) time.Duration {
+	return s.roundedTime(t).Add(s.candleInterval).Sub(t)
+}
+
+// CurrentBucket returns the boundaries of the candle interval in progress for a pair and
+// the time left until it rolls over, all in milliseconds. The boundaries are simulated
+// time, the remaining time is wall clock time.
+func (s *DataService) CurrentBucket(symbol string) (map[string]any, error) {
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	start := s.roundedTime(now)
+	return map[string]any{
+		"symbol":      pair.Symbol,
+		"start":       start.UnixMilli(),
+		"end":         start.Add(s.candleInterval).UnixMilli(),
+		"remainingMs": s.clock.Real(s.untilNextCandle(now)).Milliseconds(),
+	}, nil
 }
 
 // initializeCurrentCandle gets or creates the current candle.
@@ -261,9 +657,9 @@ func (s *DataService) initializeCurrentCandle(
 		return pair.CandleData[len(pair.CandleData)-1]
 	}
 
-	roundedTime := getRoundedTime()
+	roundedTime := s.roundedTime(s.clock.Now())
 	return models.CandleData{
-		Time:   roundedTime.Unix() * timestampMultiplier,
+		Time:   roundedTime.UnixMilli(),
 		Open:   pair.LastPrice,
 		High:   pair.LastPrice,
 		Low:    pair.LastPrice,
@@ -278,25 +674,31 @@ func (s *DataService) handlePriceUpdate(pair *models.TradingPair, currentCandle
 	s.BroadcastUpdate(pair)
 }
 
-// handleCandleUpdate handles the candle ticker update.
+// handleCandleUpdate handles the candle timer, rolling over to a new candle at interval boundaries.
 func (s *DataService) handleCandleUpdate(pair *models.TradingPair, currentCandle *models.CandleData) {
-	roundedTime := getRoundedTime()
+	roundedTime := s.roundedTime(s.clock.Now())
 
-	// Check if we need to create a new candle
-	if roundedTime.Unix()*timestampMultiplier > currentCandle.Time {
+	// Check if we need to create a new candle. A clock stepped backward must not roll over
+	// into an older interval, the current candle keeps going until time catches up.
+	switch bucket := roundedTime.UnixMilli(); {
+	case bucket > currentCandle.Time:
 		s.createNewCandle(pair, currentCandle, roundedTime)
+		s.broadcastClosedKlines(pair, roundedTime)
 		s.BroadcastUpdate(pair)
+	case bucket < currentCandle.Time:
+		s.logger.Warn("Clock moved backward, skipping candle rollover",
+			"symbol", pair.Symbol, "candleTime", currentCandle.Time, "clockBucket", bucket)
 	}
 }
 
 // SimulateTradingData simulates real-time trading data for a pair.
 func (s *DataService) SimulateTradingData(pair *models.TradingPair) {
-	// Ticker for price updates (every 500ms)
-	priceTicker := time.NewTicker(time.Duration(priceUpdateInterval) * time.Millisecond)
-	// Ticker for new candles (every 1 second)
-	candleTicker := time.NewTicker(candleTickerInterval * time.Second)
+	// Ticker for price updates (every 500ms of simulated time, faster for sub-second candles)
+	priceTicker := time.NewTicker(s.clock.Real(s.priceTick()))
+	// Timer firing at the next candle boundary
+	candleTimer := time.NewTimer(s.clock.Real(s.untilNextCandle(s.clock.Now())))
 	defer priceTicker.Stop()
-	defer candleTicker.Stop()
+	defer candleTimer.Stop()
 
 	// Ensure we have candle data
 	pair.Mutex.RLock()
@@ -318,8 +720,9 @@ func (s *DataService) SimulateTradingData(pair *models.TradingPair) {
 			return
 		case <-priceTicker.C:
 			s.handlePriceUpdate(pair, &currentCandle)
-		case <-candleTicker.C:
+		case <-candleTimer.C:
 			s.handleCandleUpdate(pair, &currentCandle)
+			candleTimer.Reset(s.clock.Real(s.untilNextCandle(s.clock.Now())))
 		}
 	}
 }
@@ -329,40 +732,69 @@ func (s *DataService) BroadcastUpdate(pair *models.TradingPair) {
 	pair.Mutex.RLock()
 	defer pair.Mutex.RUnlock()
 
+	s.broadcastKlines(pair)
+
 	// If there are no subscribers, exit
 	if len(pair.Subscribers) == 0 {
 		return
 	}
 
 	// Prepare data for sending
-	update := map[string]interface{}{
-		"symbol":      pair.Symbol,
-		"lastPrice":   pair.LastPrice,
-		"priceChange": pair.PriceChange,
-		"lastCandle":  pair.LastCandle,
+	update := map[string]any{
+		FieldSymbol:      pair.Symbol,
+		FieldLastPrice:   pair.LastPrice,
+		FieldMarkPrice:   pair.MarkPrice,
+		FieldPriceChange: pair.PriceChange,
+		FieldLastCandle:  pair.LastCandle,
 	}
 
-	// Send update to all subscribers
-	for conn := range pair.Subscribers {
-		err := conn.WriteJSON(update)
-		if err != nil {
-			s.logger.Error("Error sending update to subscriber", "error", err)
-			conn.Close()
-			delete(pair.Subscribers, conn)
+	// Queue the update for every subscriber, each gets only the fields it asked for.
+	// Writes happen in the subscribers' write pumps so a slow client doesn't hold up the others.
+	decimals := priceDecimals(pair.InitialPrice)
+	for sub := range pair.Subscribers {
+		payload := withTimeFormat(sub.Mask(update), sub.TimeFormat())
+		if sub.Formatted() {
+			payload = withFormattedFields(payload, decimals)
 		}
+		sub.Send(pair.Symbol, payload)
+	}
+}
+
+// withTimeFormat renders the candle time of an update in the subscriber's format.
+// The update is copied so other subscribers keep their own rendering.
+func withTimeFormat(update map[string]any, format string) map[string]any {
+	candle, ok := update[FieldLastCandle].(models.CandleData)
+	if !ok || format == "" || format == models.TimeFormatMillis {
+		return update
+	}
+
+	formatted := make(map[string]any, len(update))
+	for key, value := range update {
+		formatted[key] = value
 	}
+	formatted[FieldLastCandle] = candle.WithTimeFormat(format)
+	return formatted
 }
 
 // GetCandleData returns candle data for a pair.
-func (s *DataService) GetCandleData(symbol string) ([]models.CandleData, error) {
-	pair, ok := s.TradingPairs[symbol]
-	if !ok {
-		return nil, ErrTradingPairNotFound
+func (s *DataService) GetCandleData(ctx context.Context, symbol string) ([]models.CandleData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
 	}
 
 	pair.Mutex.RLock()
 	defer pair.Mutex.RUnlock()
 
+	// The caller may have given up while we were waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Return a copy of the data to avoid race conditions
 	result := make([]models.CandleData, len(pair.CandleData))
 	copy(result, pair.CandleData)
@@ -370,30 +802,77 @@ func (s *DataService) GetCandleData(symbol string) ([]models.CandleData, error)
 	return result, nil
 }
 
+// GetTicks returns up to limit of the most recent raw price ticks of a pair, oldest first.
+func (s *DataService) GetTicks(ctx context.Context, symbol string, limit int) ([]models.Tick, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+
+	// The ring is reused in place, callers get a copy
+	return pair.Ticks.Last(limit), nil
+}
+
 // AddSubscriber adds a subscriber for receiving updates.
-func (s *DataService) AddSubscriber(symbol string, conn *websocket.Conn) error {
-	pair, ok := s.TradingPairs[symbol]
-	if !ok {
-		return ErrTradingPairNotFound
+func (s *DataService) AddSubscriber(ctx context.Context, symbol string, sub *websocket.Subscriber) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	pair.Mutex.Lock()
+	pair, err := s.lockLivePair(symbol)
+	if err != nil {
+		return err
+	}
 	defer pair.Mutex.Unlock()
-	pair.Subscribers[conn] = true
-	s.logger.Info("Added subscriber for pair", "symbol", symbol, "totalSubscribers", len(pair.Subscribers))
+
+	if !sub.AddSymbol(pair.Symbol) {
+		return ErrAlreadySubscribed
+	}
+	pair.Subscribers[sub] = true
+	s.logger.Info("Added subscriber for pair", "conn", sub.ID(), "symbol", pair.Symbol, "totalSubscribers", len(pair.Subscribers))
 	return nil
 }
 
 // RemoveSubscriber removes a subscriber.
-func (s *DataService) RemoveSubscriber(symbol string, conn *websocket.Conn) error {
-	pair, ok := s.TradingPairs[symbol]
-	if !ok {
-		return ErrTradingPairNotFound
+func (s *DataService) RemoveSubscriber(ctx context.Context, symbol string, sub *websocket.Subscriber) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return err
+	}
+
+	if !sub.RemoveSymbol(pair.Symbol) {
+		return ErrNotSubscribed
 	}
 
 	pair.Mutex.Lock()
 	defer pair.Mutex.Unlock()
-	delete(pair.Subscribers, conn)
-	s.logger.Info("Removed subscriber for pair", "symbol", symbol, "remainingSubscribers", len(pair.Subscribers))
+	delete(pair.Subscribers, sub)
+	s.logger.Info("Removed subscriber for pair", "conn", sub.ID(), "symbol", pair.Symbol, "remainingSubscribers", len(pair.Subscribers))
 	return nil
 }
+
+// RemoveSubscriberFromAll removes a subscriber from every pair and kline it is subscribed to.
+func (s *DataService) RemoveSubscriberFromAll(ctx context.Context, sub *websocket.Subscriber) {
+	for _, symbol := range sub.Symbols() {
+		if err := s.RemoveSubscriber(ctx, symbol, sub); err != nil {
+			s.logger.Error("Error removing subscriber", "conn", sub.ID(), "symbol", symbol, "error", err)
+		}
+	}
+	for _, k := range sub.Klines() {
+		if err := s.RemoveKlineSubscriber(ctx, k.Symbol, k.Interval, sub); err != nil {
+			s.logger.Error("Error removing kline subscriber", "conn", sub.ID(), "symbol", k.Symbol,
+				"interval", FormatInterval(k.Interval), "error", err)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestGenerateHistoryBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget time.Duration
+		want   int
+	}{
+		{name: "no budget", budget: 0, want: maxCandleCount},
+		{name: "ample budget", budget: time.Minute, want: maxCandleCount},
+		{name: "exhausted budget keeps one candle", budget: time.Nanosecond, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, func(cfg *config.Config) { cfg.HistoryBudget = tt.budget })
+
+			candles := s.generateHistory(100)
+			if len(candles) != tt.want {
+				t.Fatalf("got %d candles, want %d", len(candles), tt.want)
+			}
+			if last, now := candles[len(candles)-1].Time, s.roundedTime(s.clock.Now()).UnixMilli(); last != now {
+				t.Errorf("last candle at %d, want the current interval %d", last, now)
+			}
+			for i := 1; i < len(candles); i++ {
+				if step := candles[i].Time - candles[i-1].Time; step != s.candleInterval.Milliseconds() {
+					t.Fatalf("candle %d is %dms after the one before, want %s", i, step, s.candleInterval)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInitialCandleDataWithConcurrentReaders(t *testing.T) {
+	s := newTestService(t, nil)
+	pair, _, err := s.AddTradingPair(context.Background(), "BTCUSDT", ptr(100.0), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers must always see a complete history, never one being generated
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				candles, err := s.GetCandleData(context.Background(), "BTCUSDT")
+				if err != nil || len(candles) < maxCandleCount {
+					t.Errorf("got %d candles, err %v", len(candles), err)
+					return
+				}
+			}
+		}()
+	}
+
+	for range 20 {
+		s.GenerateInitialCandleData(pair)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestGetCandleDataEmptyPairConcurrent(t *testing.T) {
+	s := newTestService(t, nil)
+	pair := NewTradingPair("BTCUSDT", 100)
+	s.pairsMu.Lock()
+	s.pairs[pair.Symbol] = pair
+	s.pairsMu.Unlock()
+
+	// Reading a pair without history must neither generate one under the read lock nor see
+	// a history half written, while another goroutine fills it in. Run with -race.
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for range 200 {
+				candles, err := s.GetCandleData(context.Background(), "BTCUSDT")
+				if err != nil || (len(candles) != 0 && len(candles) < maxCandleCount) {
+					t.Errorf("got %d candles, err %v, want none or a full history", len(candles), err)
+					return
+				}
+			}
+		}()
+	}
+
+	close(start)
+	s.GenerateInitialCandleData(pair)
+	wg.Wait()
+
+	candles, err := s.GetCandleData(context.Background(), "BTCUSDT")
+	if err != nil || len(candles) != maxCandleCount {
+		t.Fatalf("got %d candles, err %v, want the generated history", len(candles), err)
+	}
+}
+
+func TestAddTradingPairConcurrentAddsCreateOnce(t *testing.T) {
+	s := newTestService(t, nil)
+
+	const adders = 8
+	var created, exists int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range adders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := s.AddTradingPair(context.Background(), "DOGEUSDT", ptr(0.2), nil, false)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case ok:
+				created++
+			case errors.Is(err, ErrTradingPairExists):
+				exists++
+			default:
+				t.Errorf("unexpected result: created %v, err %v", ok, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 || exists != adders-1 {
+		t.Fatalf("created %d, rejected %d, want 1 and %d", created, exists, adders-1)
+	}
+}
+
+// TestSubscribeWhileReAdding subscribes and unsubscribes while the pair is removed and added
+// again, so subscribes race the removal between looking the pair up and locking it. Run it
+// with -race.
+func TestSubscribeWhileReAdding(t *testing.T) {
+	s := newTestService(t, nil)
+	dial := newDialer(t, nil)
+
+	const (
+		subscribers = 4
+		readds      = 200
+	)
+	pairs := []*models.TradingPair{addIdlePair(t, s, "ETHUSDT")}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range subscribers {
+		sub, _ := dial()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				err := s.AddSubscriber(context.Background(), "ETHUSDT", sub)
+				if err != nil && !errors.Is(err, ErrTradingPairNotFound) {
+					t.Errorf("subscribing: %v", err)
+					return
+				}
+				err = s.RemoveSubscriber(context.Background(), "ETHUSDT", sub)
+				if err != nil && !errors.Is(err, ErrTradingPairNotFound) && !errors.Is(err, ErrNotSubscribed) {
+					t.Errorf("unsubscribing: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for range readds {
+		if err := s.RemoveTradingPair(context.Background(), "ETHUSDT"); err != nil {
+			t.Fatalf("removing pair: %v", err)
+		}
+
+		pair := NewTradingPair("ETHUSDT", 3000)
+		s.pairsMu.Lock()
+		s.pairs[pair.Symbol] = pair
+		s.pairsMu.Unlock()
+		pairs = append(pairs, pair)
+	}
+	close(stop)
+	wg.Wait()
+
+	// A subscriber attached to a removed pair would never get another update
+	for i, pair := range pairs[:len(pairs)-1] {
+		pair.Mutex.RLock()
+		attached := len(pair.Subscribers)
+		pair.Mutex.RUnlock()
+		if attached != 0 {
+			t.Errorf("removed pair %d holds %d subscribers", i, attached)
+		}
+	}
+}
+
+func TestSubscribeRetriesRemovedPair(t *testing.T) {
+	s := newTestService(t, nil)
+	sub, _ := newDialer(t, nil)()
+	removed := addIdlePair(t, s, "ETHUSDT")
+
+	// The subscribe looks the pair up and waits for its lock, meanwhile the pair is removed
+	// and added again
+	removed.Mutex.Lock()
+	subscribed := make(chan error)
+	go func() { subscribed <- s.AddSubscriber(context.Background(), "ETHUSDT", sub) }()
+	time.Sleep(50 * time.Millisecond)
+	readded := NewTradingPair("ETHUSDT", 3000)
+	s.pairsMu.Lock()
+	s.pairs[readded.Symbol] = readded
+	s.pairsMu.Unlock()
+	removed.Removed = true
+	removed.Mutex.Unlock()
+
+	if err := <-subscribed; err != nil {
+		t.Fatalf("subscribing: %v", err)
+	}
+	removed.Mutex.RLock()
+	defer removed.Mutex.RUnlock()
+	readded.Mutex.RLock()
+	defer readded.Mutex.RUnlock()
+	if removed.Subscribers[sub] || !readded.Subscribers[sub] {
+		t.Errorf("subscriber attached to the removed pair %v, want the re-added one %v",
+			removed.Subscribers[sub], readded.Subscribers[sub])
+	}
+}
+
+func TestHandleCandleUpdateClockSteppedBackward(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) {
+		cfg.CandleInterval = time.Minute
+		cfg.CandleAlignment = config.CandleAlignmentUTC
+	})
+	clock := useFakeClock(s, time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC))
+	pair := addIdlePair(t, s, "ETHUSDT")
+	current := s.initializeCurrentCandle(pair)
+	// history returns the number of stored candles and the time of the last one.
+	history := func() (int, int64) {
+		pair.Mutex.RLock()
+		defer pair.Mutex.RUnlock()
+		return len(pair.CandleData), pair.CandleData[len(pair.CandleData)-1].Time
+	}
+	noon := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	count, _ := history()
+	if current.Time != noon {
+		t.Fatalf("current candle at %d, want %d", current.Time, noon)
+	}
+
+	// A step back by two intervals keeps the current candle and stores nothing
+	clock.Step(-2 * time.Minute)
+	s.handleCandleUpdate(pair, &current)
+	if n, last := history(); current.Time != noon || n != count || last != noon {
+		t.Errorf("after stepping back: current candle at %d, %d stored up to %d, want %d, %d stored up to %d",
+			current.Time, n, last, noon, count, noon)
+	}
+
+	// Once time passes the current candle again it rolls over as usual, the closed candle
+	// staying the last stored one
+	clock.Step(3 * time.Minute)
+	s.handleCandleUpdate(pair, &current)
+	if next := noon + time.Minute.Milliseconds(); current.Time != next {
+		t.Errorf("after catching up current candle at %d, want %d", current.Time, next)
+	}
+	if _, last := history(); last != noon {
+		t.Errorf("after catching up last stored candle at %d, want %d", last, noon)
+	}
+}
+
+func BenchmarkGenerateInitialCandleData(b *testing.B) {
+	s := newTestService(b, func(cfg *config.Config) { cfg.HistoryBudget = 0 })
+	pair := NewTradingPair("BTCUSDT", 100)
+
+	for b.Loop() {
+		s.GenerateInitialCandleData(pair)
+	}
+}
+
+func TestAddTradingPairUpsertMovesLivePrice(t *testing.T) {
+	s := newTestService(t, nil)
+	ctx := context.Background()
+
+	if _, _, err := s.AddTradingPair(ctx, "TESTUSDT", ptr(100.0), nil, false); err != nil {
+		t.Fatalf("adding pair: %v", err)
+	}
+	pair, created, err := s.AddTradingPair(ctx, "TESTUSDT", ptr(1000.0), nil, true)
+	if err != nil {
+		t.Fatalf("upserting pair: %v", err)
+	}
+	if created {
+		t.Fatal("upsert of an existing pair reported it as created")
+	}
+
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+	if pair.InitialPrice != 1000 {
+		t.Errorf("initial price = %v, want 1000", pair.InitialPrice)
+	}
+	// A tick may have happened since, it moves the price by a fraction of a percent
+	for field, price := range map[string]float64{"last": pair.LastPrice, "mark": pair.MarkPrice} {
+		if price < 900 || price > 1100 {
+			t.Errorf("%s price = %v, want about 1000", field, price)
+		}
+	}
+}
+
+func TestInitializeTradingPairs(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) {
+		cfg.Pairs = []config.PairConfig{{Symbol: "BTCUSDT", InitialPrice: 50000}, {Symbol: "DOGEUSDT", InitialPrice: 0.2}}
+	})
+	s.InitializeTradingPairs()
+
+	pairs := s.Pairs()
+	if len(pairs) != 2 {
+		t.Fatalf("got %d pairs, want 2", len(pairs))
+	}
+	for _, pair := range pairs {
+		pair.Mutex.RLock()
+		symbol, price, candles := pair.Symbol, pair.InitialPrice, len(pair.CandleData)
+		pair.Mutex.RUnlock()
+
+		if want := map[string]float64{"BTCUSDT": 50000, "DOGEUSDT": 0.2}[symbol]; price != want {
+			t.Errorf("%s starts at %v, want %v", symbol, price, want)
+		}
+		if candles == 0 {
+			t.Errorf("%s has no history", symbol)
+		}
+	}
+}
+
+func TestAddTradingPairLimit(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) { cfg.MaxPairs = 2 })
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"first pair", func() error { _, _, err := s.AddTradingPair(ctx, "AAAUSDT", ptr(1.0), nil, false); return err }, nil},
+		{"second pair", func() error { _, _, err := s.AddTradingPair(ctx, "BBBUSDT", ptr(1.0), nil, false); return err }, nil},
+		{"over the limit", func() error { _, _, err := s.AddTradingPair(ctx, "CCCUSDT", ptr(1.0), nil, false); return err }, ErrPairLimitReached},
+		{"upsert at the limit", func() error { _, _, err := s.AddTradingPair(ctx, "AAAUSDT", ptr(2.0), nil, true); return err }, nil},
+		{"remove a pair", func() error { return s.RemoveTradingPair(ctx, "BBBUSDT") }, nil},
+		{"room again", func() error { _, _, err := s.AddTradingPair(ctx, "CCCUSDT", ptr(1.0), nil, false); return err }, nil},
+	}
+
+	// Steps depend on the pairs left by the ones before, so a failure stops the sequence
+	for _, step := range steps {
+		if err := step.run(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: got error %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+}
+
+func TestUpdateRecords(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64 // Seen at times 1, 2, 3...
+		wantHigh models.PriceRecord
+		wantLow  models.PriceRecord
+	}{
+		{"first price sets both", []float64{5}, models.PriceRecord{Price: 5, Time: 1}, models.PriceRecord{Price: 5, Time: 1}},
+		{"rising", []float64{5, 6, 7}, models.PriceRecord{Price: 7, Time: 3}, models.PriceRecord{Price: 5, Time: 1}},
+		{"falling", []float64{5, 4, 3}, models.PriceRecord{Price: 5, Time: 1}, models.PriceRecord{Price: 3, Time: 3}},
+		{"equal price keeps the first time", []float64{5, 7, 7, 3, 3}, models.PriceRecord{Price: 7, Time: 2}, models.PriceRecord{Price: 3, Time: 4}},
+		{"inside the range", []float64{5, 9, 1, 4}, models.PriceRecord{Price: 9, Time: 2}, models.PriceRecord{Price: 1, Time: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair := &models.TradingPair{}
+			for i, price := range tt.prices {
+				updateRecords(pair, price, int64(i+1))
+			}
+			if pair.AllTimeHigh != tt.wantHigh || pair.AllTimeLow != tt.wantLow {
+				t.Fatalf("high %+v, low %+v, want %+v and %+v", pair.AllTimeHigh, pair.AllTimeLow, tt.wantHigh, tt.wantLow)
+			}
+		})
+	}
+}
+
+func TestGenerateInitialCandleDataRecords(t *testing.T) {
+	s := newTestService(t, nil)
+	pair := NewTradingPair("TESTUSDT", 100)
+	// Records of an earlier history don't survive a regeneration
+	pair.AllTimeHigh = models.PriceRecord{Price: 1e9, Time: 1}
+	pair.AllTimeLow = models.PriceRecord{Price: 1e-9, Time: 1}
+	s.GenerateInitialCandleData(pair)
+
+	high, low := pair.CandleData[0], pair.CandleData[0]
+	for _, candle := range pair.CandleData {
+		if candle.High > high.High {
+			high = candle
+		}
+		if candle.Low < low.Low {
+			low = candle
+		}
+	}
+	if want := (models.PriceRecord{Price: high.High, Time: high.Time}); pair.AllTimeHigh != want {
+		t.Errorf("all-time high = %+v, want %+v", pair.AllTimeHigh, want)
+	}
+	if want := (models.PriceRecord{Price: low.Low, Time: low.Time}); pair.AllTimeLow != want {
+		t.Errorf("all-time low = %+v, want %+v", pair.AllTimeLow, want)
+	}
+}
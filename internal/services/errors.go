@@ -3,5 +3,17 @@ package services
 import "errors"
 
 var (
-	ErrTradingPairNotFound = errors.New("trading pair not found")
+	ErrTradingPairNotFound  = errors.New("trading pair not found")
+	ErrUnknownField         = errors.New("unknown broadcast field")
+	ErrAlreadySubscribed    = errors.New("already subscribed")
+	ErrNotSubscribed        = errors.New("not subscribed")
+	ErrTradingPairExists    = errors.New("trading pair already exists")
+	ErrInitialPriceRequired = errors.New("initial price is required")
+	ErrInvalidCorrelation   = errors.New("invalid correlation matrix")
+	ErrUnsupportedInterval  = errors.New("unsupported candle interval")
+	ErrSymbolIsAlias        = errors.New("symbol is an alias of another pair")
+	ErrPairLimitReached     = errors.New("trading pair limit reached")
+	ErrInsufficientHistory  = errors.New("not enough candle history")
+	ErrTooManyBricks        = errors.New("box size yields too many renko bricks")
+	ErrInvalidCandles       = errors.New("invalid candle history")
 )
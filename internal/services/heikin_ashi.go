@@ -0,0 +1,32 @@
+package services
+
+import (
+	"math"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// HeikinAshi transforms regular candles into Heikin-Ashi candles. Each candle's open is the
+// midpoint of the previous Heikin-Ashi open and close, so the series must be transformed
+// from its start; the first candle is seeded with the midpoint of its own open and close.
+// Candles must be sorted by time, times and volumes are kept.
+func HeikinAshi(candles []models.CandleData) []models.CandleData {
+	result := make([]models.CandleData, len(candles))
+	for i, candle := range candles {
+		haClose := (candle.Open + candle.High + candle.Low + candle.Close) / 4
+		haOpen := (candle.Open + candle.Close) / 2
+		if i > 0 {
+			haOpen = (result[i-1].Open + result[i-1].Close) / 2
+		}
+
+		result[i] = models.CandleData{
+			Time:   candle.Time,
+			Open:   haOpen,
+			High:   math.Max(candle.High, math.Max(haOpen, haClose)),
+			Low:    math.Min(candle.Low, math.Min(haOpen, haClose)),
+			Close:  haClose,
+			Volume: candle.Volume,
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestHeikinAshi(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.CandleData
+		want    []models.CandleData
+	}{
+		{"empty", nil, []models.CandleData{}},
+		{
+			"first candle seeded from itself",
+			[]models.CandleData{{Time: 1, Open: 10, High: 14, Low: 8, Close: 12, Volume: 3}},
+			[]models.CandleData{{Time: 1, Open: 11, High: 14, Low: 8, Close: 11, Volume: 3}},
+		},
+		{
+			"open from the previous Heikin-Ashi candle",
+			[]models.CandleData{
+				{Time: 1, Open: 10, High: 14, Low: 8, Close: 12, Volume: 3},
+				{Time: 2, Open: 12, High: 16, Low: 12, Close: 16, Volume: 5},
+			},
+			[]models.CandleData{
+				{Time: 1, Open: 11, High: 14, Low: 8, Close: 11, Volume: 3},
+				{Time: 2, Open: 11, High: 16, Low: 11, Close: 14, Volume: 5},
+			},
+		},
+		{
+			"high and low stretch to the body",
+			[]models.CandleData{
+				{Time: 1, Open: 20, High: 20, Low: 20, Close: 20},
+				{Time: 2, Open: 10, High: 10, Low: 10, Close: 10},
+				{Time: 3, Open: 30, High: 30, Low: 30, Close: 30},
+			},
+			[]models.CandleData{
+				{Time: 1, Open: 20, High: 20, Low: 20, Close: 20},
+				{Time: 2, Open: 20, High: 20, Low: 10, Close: 10},
+				{Time: 3, Open: 15, High: 30, Low: 15, Close: 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HeikinAshi(tt.candles); !slices.Equal(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+// newTestService creates a data service from the default configuration, changed by configure
+// if given. No pairs are started; pairs added by the test are removed when it ends.
+func newTestService(tb testing.TB, configure func(*config.Config)) *DataService {
+	tb.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		tb.Fatalf("loading config: %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	s, err := NewDataService(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
+	if err != nil {
+		tb.Fatalf("creating data service: %v", err)
+	}
+	tb.Cleanup(func() {
+		for _, pair := range s.Pairs() {
+			_ = s.RemoveTradingPair(context.Background(), pair.Symbol)
+		}
+	})
+	return s
+}
+
+// fakeClock is a wall clock that only moves when the test steps it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// useFakeClock makes the simulated time of s follow a fake wall clock starting at start.
+func useFakeClock(s *DataService, start time.Time) *fakeClock {
+	c := &fakeClock{now: start}
+	s.clock = simClock{origin: start, speed: 1, wall: c.Now}
+	return c
+}
+
+// Now returns the current fake time.
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Step moves the clock by d, backward when d is negative.
+func (c *fakeClock) Step(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// ptr returns a pointer to v, for optional request fields.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// addIdlePair registers a pair with generated history but no running simulation, so the
+// test controls every update. It is removed when the test ends.
+func addIdlePair(tb testing.TB, s *DataService, symbol string) *models.TradingPair {
+	tb.Helper()
+
+	pair := NewTradingPair(symbol, 100)
+	s.GenerateInitialCandleData(pair)
+	s.pairsMu.Lock()
+	s.pairs[pair.Symbol] = pair
+	s.pairsMu.Unlock()
+	return pair
+}
+
+// dialer connects clients to a WebSocket test server and returns the server side subscriber
+// with the client end of each connection.
+type dialer func() (*websocket.Subscriber, *gorilla.Conn)
+
+// newDialer starts a WebSocket test server on a manager with the default delivery settings,
+// changed by configure if given. The server reads like the real handler, closing the
+// subscriber when the connection fails.
+func newDialer(tb testing.TB, configure func(*config.WebSocketConfig)) dialer {
+	tb.Helper()
+
+	cfg, err := config.Load()
+	if err != nil {
+		tb.Fatalf("loading config: %v", err)
+	}
+	if configure != nil {
+		configure(&cfg.WebSocket)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := websocket.NewWebSocketManager(logger, cfg.WebSocket, cfg.JSONNaming, cfg.JSONNumbers, metrics.New())
+
+	subs := make(chan *websocket.Subscriber, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := m.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		subs <- sub
+		for {
+			if _, _, err := sub.Conn().ReadMessage(); err != nil {
+				sub.Close()
+				return
+			}
+		}
+	}))
+	tb.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	return func() (*websocket.Subscriber, *gorilla.Conn) {
+		tb.Helper()
+
+		client, _, err := gorilla.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			tb.Fatalf("dial: %v", err)
+		}
+		tb.Cleanup(func() { client.Close() })
+
+		select {
+		case sub := <-subs:
+			return sub, client
+		case <-time.After(time.Second):
+			tb.Fatal("server did not accept the connection")
+			return nil, nil
+		}
+	}
+}
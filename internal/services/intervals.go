@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// Intervals returns the candle intervals clients may request, the base interval first.
+func (s *DataService) Intervals() []time.Duration {
+	return slices.Clone(s.intervals)
+}
+
+// BaseInterval returns the period of the simulated candles all other intervals are built from.
+func (s *DataService) BaseInterval() time.Duration {
+	return s.candleInterval
+}
+
+// GetCandleDataForInterval returns the candles of a pair aggregated to one of the configured
+// intervals. The base interval returns the simulated candles as they are.
+func (s *DataService) GetCandleDataForInterval(
+	ctx context.Context,
+	symbol string,
+	interval time.Duration,
+) ([]models.CandleData, error) {
+	if !slices.Contains(s.intervals, interval) {
+		return nil, ErrUnsupportedInterval
+	}
+
+	candles, err := s.GetCandleData(ctx, symbol)
+	if err != nil || interval == s.candleInterval {
+		return candles, err
+	}
+	return s.aggregateCandles(candles, interval), nil
+}
+
+// aggregateCandles merges consecutive base candles into candles of the given interval.
+// Buckets are aligned the same way as base candles, so the oldest bucket may be partial
+// when the history starts in the middle of it.
+func (s *DataService) aggregateCandles(candles []models.CandleData, interval time.Duration) []models.CandleData {
+	result := make([]models.CandleData, 0, len(candles)*int(s.candleInterval)/int(interval)+1)
+	for _, candle := range candles {
+		bucket := s.alignTime(time.UnixMilli(candle.Time), interval).UnixMilli()
+
+		last := len(result) - 1
+		if last < 0 || result[last].Time != bucket {
+			candle.Time = bucket
+			result = append(result, candle)
+			continue
+		}
+
+		agg := &result[last]
+		agg.High = max(agg.High, candle.High)
+		agg.Low = min(agg.Low, candle.Low)
+		agg.Close = candle.Close
+		if s.volumeMode == config.VolumeModeCumulative {
+			agg.Volume = candle.Volume // Already the running total
+		} else {
+			agg.Volume += candle.Volume
+		}
+	}
+	return result
+}
+
+// alignTime returns the start of the interval containing t. Intervals are counted from
+// midnight in the configured candle location, so boundaries fall on round clock times
+// there whatever the location of t.
+func (s *DataService) alignTime(t time.Time, interval time.Duration) time.Time {
+	t = t.In(s.location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	return midnight.Add(t.Sub(midnight) / interval * interval)
+}
+
+// FormatInterval renders an interval the short way operators write it, e.g. "15m" or "2h".
+func FormatInterval(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
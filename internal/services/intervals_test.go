@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// minuteCandles returns candles one minute apart from start, with the given closes. Each
+// opens at the close before it and has a volume of 1.
+func minuteCandles(start time.Time, closes ...float64) []models.CandleData {
+	candles := make([]models.CandleData, len(closes))
+	open := closes[0]
+	for i, price := range closes {
+		candles[i] = models.CandleData{
+			Time:   start.Add(time.Duration(i) * time.Minute).UnixMilli(),
+			Open:   open,
+			High:   max(open, price) + 1,
+			Low:    min(open, price) - 1,
+			Close:  price,
+			Volume: 1,
+		}
+		open = price
+	}
+	return candles
+}
+
+func TestAggregateCandles(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) {
+		cfg.CandleInterval = time.Minute
+		cfg.CandleAlignment = config.CandleAlignmentUTC
+	})
+	// The history starts 3 minutes into a 5 minute bucket
+	start := time.Date(2026, 1, 1, 12, 3, 0, 0, time.UTC)
+	candles := minuteCandles(start, 100, 102, 101, 99, 98, 97, 103)
+
+	got := s.aggregateCandles(candles, 5*time.Minute)
+	want := []models.CandleData{
+		{Time: start.Add(-3 * time.Minute).UnixMilli(), Open: 100, High: 103, Low: 99, Close: 102, Volume: 2},
+		{Time: start.Add(2 * time.Minute).UnixMilli(), Open: 102, High: 104, Low: 96, Close: 103, Volume: 5},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestGetCandleDataForInterval(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) {
+		cfg.CandleInterval = time.Minute
+		cfg.CandleIntervals = []time.Duration{5 * time.Minute}
+	})
+	addIdlePair(t, s, "TESTUSDT")
+	ctx := context.Background()
+
+	base, err := s.GetCandleDataForInterval(ctx, "TESTUSDT", time.Minute)
+	if err != nil {
+		t.Fatalf("base interval: %v", err)
+	}
+	aggregated, err := s.GetCandleDataForInterval(ctx, "TESTUSDT", 5*time.Minute)
+	if err != nil {
+		t.Fatalf("aggregated interval: %v", err)
+	}
+	// The oldest and the current bucket may both be partial
+	if n := len(aggregated); n < len(base)/5 || n > len(base)/5+2 {
+		t.Errorf("%d base candles aggregate to %d", len(base), n)
+	}
+
+	if _, err := s.GetCandleDataForInterval(ctx, "TESTUSDT", 15*time.Minute); !errors.Is(err, ErrUnsupportedInterval) {
+		t.Errorf("unconfigured interval: got %v, want ErrUnsupportedInterval", err)
+	}
+	if got, want := s.Intervals(), []time.Duration{time.Minute, 5 * time.Minute}; !slices.Equal(got, want) {
+		t.Errorf("intervals %v, want %v", got, want)
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{time.Second, "1s"},
+		{90 * time.Second, "1m30s"},
+		{15 * time.Minute, "15m"},
+		{2 * time.Hour, "2h"},
+		{90 * time.Minute, "1h30m"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatInterval(tt.interval); got != tt.want {
+			t.Errorf("FormatInterval(%s) = %s, want %s", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestAlignTime(t *testing.T) {
+	// A zone with a half hour offset, so hour aligned boundaries differ from UTC ones
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	at := time.Date(2026, 3, 10, 10, 40, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		t        time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{"utc 5m", time.UTC, at, 5 * time.Minute, time.Date(2026, 3, 10, 10, 40, 0, 0, time.UTC)},
+		{"utc 4h", time.UTC, at, 4 * time.Hour, time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"utc day", time.UTC, at, day, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"utc ignores the zone of t", time.UTC, at.In(kolkata), 4 * time.Hour, time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"local 5m", kolkata, at, 5 * time.Minute, time.Date(2026, 3, 10, 10, 40, 0, 0, time.UTC)},
+		{"local 4h", kolkata, at, 4 * time.Hour, time.Date(2026, 3, 10, 16, 0, 0, 0, kolkata)},
+		{"local day", kolkata, at, day, time.Date(2026, 3, 10, 0, 0, 0, 0, kolkata)},
+		{"local day before local midnight", kolkata, time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC), day,
+			time.Date(2026, 3, 10, 0, 0, 0, 0, kolkata)},
+		{"local day after local midnight", kolkata, time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC), day,
+			time.Date(2026, 3, 11, 0, 0, 0, 0, kolkata)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, nil)
+			s.location = tt.location
+			if got := s.alignTime(tt.t, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("alignTime(%v, %v) = %v, want %v", tt.t, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandleLocation(t *testing.T) {
+	if got := candleLocation(config.CandleAlignmentUTC); got != time.UTC {
+		t.Errorf("utc alignment uses %v", got)
+	}
+	if got := candleLocation(config.CandleAlignmentLocal); got != time.Local {
+		t.Errorf("local alignment uses %v", got)
+	}
+}
+
+func TestSecondCandlesRollEverySecond(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) {
+		cfg.CandleInterval = time.Second
+		cfg.CandleIntervals = nil
+		cfg.CandleAlignment = config.CandleAlignmentUTC
+	})
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := useFakeClock(s, start.Add(250*time.Millisecond))
+	pair := addIdlePair(t, s, "ETHUSDT")
+	current := s.initializeCurrentCandle(pair)
+
+	for i := 1; i <= 5; i++ {
+		// Half a second in, the candle keeps going and the timer waits for the boundary
+		clock.Step(500 * time.Millisecond)
+		s.handleCandleUpdate(pair, &current)
+		if want := start.Add(time.Duration(i-1) * time.Second).UnixMilli(); current.Time != want {
+			t.Fatalf("second %d, mid-candle: current candle at %d, want %d", i, current.Time, want)
+		}
+		if wait := s.untilNextCandle(clock.Now()); wait != 250*time.Millisecond {
+			t.Errorf("second %d: next candle in %s, want 250ms", i, wait)
+		}
+
+		clock.Step(500 * time.Millisecond)
+		s.handleCandleUpdate(pair, &current)
+		if want := start.Add(time.Duration(i) * time.Second).UnixMilli(); current.Time != want {
+			t.Fatalf("second %d: current candle at %d, want %d", i, current.Time, want)
+		}
+	}
+
+	// The closed candles are stored one second apart
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+	last := pair.CandleData[len(pair.CandleData)-5:]
+	for i, candle := range last {
+		if want := start.Add(time.Duration(i) * time.Second).UnixMilli(); candle.Time != want {
+			t.Errorf("stored candle %d at %d, want %d", i, candle.Time, want)
+		}
+	}
+}
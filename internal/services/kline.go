@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+// messageTypeKline is the type of the frames sent to kline subscribers.
+const messageTypeKline = "kline"
+
+// klineMessage carries a bar of one interval, aggregated from the base candles.
+type klineMessage struct {
+	Type     string                 `json:"type"`
+	Symbol   string                 `json:"symbol"`
+	Interval string                 `json:"interval"`
+	Closed   bool                   `json:"closed"` // The bar is complete and won't change anymore.
+	Candle   models.FormattedCandle `json:"candle"`
+}
+
+// AddKlineSubscriber subscribes a client to the bars of a pair in one of the candle intervals.
+func (s *DataService) AddKlineSubscriber(
+	ctx context.Context,
+	symbol string,
+	interval time.Duration,
+	sub *websocket.Subscriber,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !slices.Contains(s.intervals, interval) {
+		return ErrUnsupportedInterval
+	}
+
+	pair, err := s.lockLivePair(symbol)
+	if err != nil {
+		return err
+	}
+	defer pair.Mutex.Unlock()
+
+	if !sub.AddKline(websocket.Kline{Symbol: pair.Symbol, Interval: interval}) {
+		return ErrAlreadySubscribed
+	}
+	if pair.KlineSubscribers[interval] == nil {
+		pair.KlineSubscribers[interval] = make(map[*websocket.Subscriber]bool)
+	}
+	pair.KlineSubscribers[interval][sub] = true
+	s.logger.Info("Added kline subscriber for pair", "conn", sub.ID(), "symbol", pair.Symbol,
+		"interval", FormatInterval(interval))
+	return nil
+}
+
+// RemoveKlineSubscriber unsubscribes a client from the bars of a pair in one interval.
+func (s *DataService) RemoveKlineSubscriber(
+	ctx context.Context,
+	symbol string,
+	interval time.Duration,
+	sub *websocket.Subscriber,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return err
+	}
+
+	if !sub.RemoveKline(websocket.Kline{Symbol: pair.Symbol, Interval: interval}) {
+		return ErrNotSubscribed
+	}
+
+	pair.Mutex.Lock()
+	defer pair.Mutex.Unlock()
+	delete(pair.KlineSubscribers[interval], sub)
+	if len(pair.KlineSubscribers[interval]) == 0 {
+		delete(pair.KlineSubscribers, interval)
+	}
+	s.logger.Info("Removed kline subscriber for pair", "conn", sub.ID(), "symbol", pair.Symbol,
+		"interval", FormatInterval(interval))
+	return nil
+}
+
+// broadcastKlines sends kline subscribers the bar in progress of their interval, built from
+// the base candles of the bar so far and the live candle. The caller must hold the pair's
+// read lock.
+func (s *DataService) broadcastKlines(pair *models.TradingPair) {
+	for interval, subscribers := range pair.KlineSubscribers {
+		start := s.alignTime(time.UnixMilli(pair.LastCandle.Time), interval).UnixMilli()
+		// The history may end in a stale copy of the live candle, which is left out
+		candles := make([]models.CandleData, 0, int(interval/s.candleInterval))
+		for _, candle := range candlesFrom(pair.CandleData, start) {
+			if candle.Time < pair.LastCandle.Time {
+				candles = append(candles, candle)
+			}
+		}
+		candles = append(candles, pair.LastCandle)
+
+		bar := s.aggregateCandles(candles, interval)[0]
+		for sub := range subscribers {
+			k := websocket.Kline{Symbol: pair.Symbol, Interval: interval}
+			if !sub.SendKline(k, klineFrame(pair.Symbol, interval, bar, false, sub)) {
+				s.logger.Debug("Kline update not queued", "conn", sub.ID(), "symbol", pair.Symbol)
+			}
+		}
+	}
+}
+
+// broadcastClosedKlines sends kline subscribers the bars that ended at boundary, the start
+// of a new base candle. Closed bars are sent like control frames, so rate limits and
+// batching never drop or merge them.
+func (s *DataService) broadcastClosedKlines(pair *models.TradingPair, boundary time.Time) {
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+
+	for interval, subscribers := range pair.KlineSubscribers {
+		if !s.alignTime(boundary, interval).Equal(boundary) {
+			continue // The bar of this interval is still open
+		}
+
+		start, end := boundary.Add(-interval).UnixMilli(), boundary.UnixMilli()
+		candles := candlesFrom(pair.CandleData, start)
+		candles = candles[:len(candles)-len(candlesFrom(candles, end))]
+		if len(candles) == 0 {
+			continue
+		}
+
+		bar := s.aggregateCandles(candles, interval)[0]
+		k := websocket.Kline{Symbol: pair.Symbol, Interval: interval}
+		for sub := range subscribers {
+			if !sub.SendKlineClosed(k, klineFrame(pair.Symbol, interval, bar, true, sub)) {
+				s.logger.Warn("Closed kline not delivered, send queue full", "conn", sub.ID(), "symbol", pair.Symbol)
+			}
+		}
+	}
+}
+
+// klineFrame builds the frame of a bar in the subscriber's time format.
+func klineFrame(
+	symbol string,
+	interval time.Duration,
+	bar models.CandleData,
+	closed bool,
+	sub *websocket.Subscriber,
+) klineMessage {
+	return klineMessage{
+		Type:     messageTypeKline,
+		Symbol:   symbol,
+		Interval: FormatInterval(interval),
+		Closed:   closed,
+		Candle:   bar.WithTimeFormat(sub.TimeFormat()),
+	}
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"math"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+// Momentum classes of a pair, derived from its price change.
+const (
+	MomentumStrongUp   = "strong_up"
+	MomentumUp         = "up"
+	MomentumFlat       = "flat"
+	MomentumDown       = "down"
+	MomentumStrongDown = "strong_down"
+)
+
+// Momentum classifies a price change in percent. Changes within the flat threshold are
+// flat, changes reaching the strong threshold are strong, anything between is up or down.
+func Momentum(priceChange float64, thresholds config.MomentumConfig) string {
+	magnitude := math.Abs(priceChange)
+	switch {
+	case magnitude <= thresholds.FlatThreshold:
+		return MomentumFlat
+	case magnitude >= thresholds.StrongThreshold && priceChange > 0:
+		return MomentumStrongUp
+	case magnitude >= thresholds.StrongThreshold:
+		return MomentumStrongDown
+	case priceChange > 0:
+		return MomentumUp
+	default:
+		return MomentumDown
+	}
+}
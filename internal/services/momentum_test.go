@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+func TestMomentum(t *testing.T) {
+	thresholds := config.MomentumConfig{FlatThreshold: 0.5, StrongThreshold: 5}
+
+	tests := []struct {
+		change float64
+		want   string
+	}{
+		{0, MomentumFlat},
+		{0.5, MomentumFlat},
+		{-0.5, MomentumFlat},
+		{0.51, MomentumUp},
+		{-4.99, MomentumDown},
+		{5, MomentumStrongUp},
+		{12, MomentumStrongUp},
+		{-5, MomentumStrongDown},
+	}
+
+	for _, tt := range tests {
+		if got := Momentum(tt.change, thresholds); got != tt.want {
+			t.Errorf("Momentum(%g) = %s, want %s", tt.change, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"math"
+	"strconv"
+)
+
+// Bounds of the decimals prices are formatted with.
+const (
+	minPriceDecimals = 2
+	maxPriceDecimals = 8
+
+	// priceSignificantDigits is how many digits a formatted price shows at least, so cheap
+	// pairs get more decimals than expensive ones (95012.30 but 0.55123).
+	priceSignificantDigits = 5
+
+	// percentDecimals is the precision of formatted percentages.
+	percentDecimals = 2
+)
+
+// formattedFields maps the numeric broadcast fields to their formatted string companions.
+var formattedFields = map[string]string{
+	FieldLastPrice:   "lastPriceStr",
+	FieldMarkPrice:   "markPriceStr",
+	FieldPriceChange: "priceChangeStr",
+}
+
+// priceDecimals returns the display precision of a pair trading around price.
+func priceDecimals(price float64) int {
+	if price <= 0 {
+		return minPriceDecimals
+	}
+	decimals := priceSignificantDigits - int(math.Ceil(math.Log10(price)))
+	return min(max(decimals, minPriceDecimals), maxPriceDecimals)
+}
+
+// formatPercent renders a percentage with an explicit sign, e.g. +2.34% or -0.50%. Values
+// that round to zero are shown without a sign.
+func formatPercent(value float64) string {
+	scale := math.Pow10(percentDecimals)
+	rounded := math.Round(value*scale) / scale
+	formatted := strconv.FormatFloat(math.Abs(rounded), 'f', percentDecimals, 64) + "%"
+	switch {
+	case rounded > 0:
+		return "+" + formatted
+	case rounded < 0:
+		return "-" + formatted
+	default:
+		return formatted
+	}
+}
+
+// withFormattedFields adds the formatted companion of every numeric field present in the
+// update, prices with the given decimals. The update is copied so other subscribers keep
+// the plain payload.
+func withFormattedFields(update map[string]any, decimals int) map[string]any {
+	formatted := make(map[string]any, len(update)+len(formattedFields))
+	for key, value := range update {
+		formatted[key] = value
+		number, ok := value.(float64)
+		name, hasFormat := formattedFields[key]
+		if !ok || !hasFormat {
+			continue
+		}
+		if key == FieldPriceChange {
+			formatted[name] = formatPercent(number)
+		} else {
+			formatted[name] = strconv.FormatFloat(number, 'f', decimals, 64)
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestPriceDecimals(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  int
+	}{
+		{95012.3, minPriceDecimals},
+		{3000, minPriceDecimals},
+		{150, 2},
+		{99.5, 3},
+		{1, 5},
+		{0.55, 5},
+		{0.02, 6},
+		{0.000012, maxPriceDecimals},
+		{0, minPriceDecimals},
+		{-1, minPriceDecimals},
+	}
+
+	for _, tt := range tests {
+		if got := priceDecimals(tt.price); got != tt.want {
+			t.Errorf("priceDecimals(%v) = %d, want %d", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{2.344, "+2.34%"},
+		{-0.5, "-0.50%"},
+		{0, "0.00%"},
+		{0.004, "0.00%"},
+		{-0.004, "0.00%"},
+		{0.005, "+0.01%"},
+		{-123.456, "-123.46%"},
+	}
+
+	for _, tt := range tests {
+		if got := formatPercent(tt.value); got != tt.want {
+			t.Errorf("formatPercent(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWithFormattedFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   map[string]any
+		decimals int
+		want     map[string]any
+	}{
+		{
+			"all fields",
+			map[string]any{FieldSymbol: "BTCUSDT", FieldLastPrice: 95012.3, FieldMarkPrice: 95010.0, FieldPriceChange: 1.5},
+			2,
+			map[string]any{
+				FieldSymbol: "BTCUSDT", FieldLastPrice: 95012.3, FieldMarkPrice: 95010.0, FieldPriceChange: 1.5,
+				"lastPriceStr": "95012.30", "markPriceStr": "95010.00", "priceChangeStr": "+1.50%",
+			},
+		},
+		{
+			"cheap pair",
+			map[string]any{FieldLastPrice: 0.551234},
+			5,
+			map[string]any{FieldLastPrice: 0.551234, "lastPriceStr": "0.55123"},
+		},
+		{
+			"masked fields get no companion",
+			map[string]any{FieldSymbol: "BTCUSDT"},
+			2,
+			map[string]any{FieldSymbol: "BTCUSDT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := maps.Clone(tt.update)
+			if got := withFormattedFields(tt.update, tt.decimals); !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !maps.Equal(tt.update, original) {
+				t.Errorf("update changed to %v, other subscribers share it", tt.update)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// Sources of a price history.
+const (
+	PriceSourceTicks   = "ticks"   // Raw price updates, used while the tick buffer covers the range.
+	PriceSourceCandles = "candles" // Candle closes, for ranges reaching further back.
+)
+
+// PriceHistory returns the price of a pair over [start, end] in milliseconds as time and
+// price points. Ranges starting within the tick buffer are served from the raw ticks, longer
+// ones from candle closes. With a positive limit the points are thinned out evenly to at
+// most limit, the latest point is always kept.
+func (s *DataService) PriceHistory(
+	ctx context.Context,
+	symbol string,
+	start, end int64,
+	limit int,
+) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pair.Mutex.RLock()
+	source := PriceSourceCandles
+	points := make([]models.Tick, 0)
+	if ticks := pair.Ticks.Last(maxTickCount); len(ticks) > 0 && ticks[0].Time <= start {
+		source = PriceSourceTicks
+		for _, tick := range ticks {
+			if tick.Time >= start && tick.Time <= end {
+				points = append(points, tick)
+			}
+		}
+	} else {
+		for _, candle := range pair.CandleData {
+			if candle.Time >= start && candle.Time <= end {
+				points = append(points, models.Tick{Time: candle.Time, Price: candle.Close})
+			}
+		}
+	}
+	pair.Mutex.RUnlock()
+
+	return map[string]any{
+		"symbol": pair.Symbol,
+		"source": source,
+		"points": samplePoints(points, limit),
+	}, nil
+}
+
+// samplePoints picks at most limit points spread evenly over the series, first and last
+// included. A single point is the latest one.
+func samplePoints(points []models.Tick, limit int) []models.Tick {
+	if limit <= 0 || len(points) <= limit {
+		return points
+	}
+	if limit == 1 {
+		return points[len(points)-1:]
+	}
+
+	sampled := make([]models.Tick, limit)
+	for i := range sampled {
+		sampled[i] = points[i*(len(points)-1)/(limit-1)]
+	}
+	return sampled
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+// Constants for the GBM price model.
+const (
+	secondsPerYear = 365 * 24 * 60 * 60 // Annualized parameters are scaled by this.
+	gbmDriftFactor = 0.5                // Ito correction: drift is reduced by half the variance.
+	boxMullerScale = -2                 // Box-Muller transform: sqrt(-2 ln u1).
+)
+
+// PriceModel produces the relative price change of a pair for one price tick.
+type PriceModel interface {
+	NextReturn(symbol string) float64
+}
+
+// randomWalkModel moves every pair uniformly within ±0.2% per tick. With a non-zero market
+// correlation each move blends a shared market draw with the pair's own draw, so pairs
+// tend to move together: sqrt(rho)*market + sqrt(1-rho)*own has correlation rho between
+// any two pairs and the same variance as an independent draw.
+type randomWalkModel struct {
+	marketWeight float64 // sqrt(rho), weight of the shared market draw.
+	ownWeight    float64 // sqrt(1-rho), weight of the pair's own draw.
+
+	mu       sync.Mutex
+	market   float64         // Current shared market draw.
+	consumed map[string]bool // Which pairs already used the current market draw.
+
+	logger *slog.Logger
+}
+
+func newRandomWalkModel(correlation float64, logger *slog.Logger) *randomWalkModel {
+	return &randomWalkModel{
+		marketWeight: math.Sqrt(correlation),
+		ownWeight:    math.Sqrt(1 - correlation),
+		consumed:     make(map[string]bool),
+		logger:       logger,
+	}
+}
+
+func (m *randomWalkModel) NextReturn(symbol string) float64 {
+	own := m.draw()
+	if m.marketWeight == 0 {
+		return own
+	}
+	return m.marketWeight*m.marketDraw(symbol) + m.ownWeight*own
+}
+
+// draw returns a uniform move from -0.2% to +0.2%.
+func (m *randomWalkModel) draw() float64 {
+	return secureFloat64(m.logger)*realtimePriceVariationMax - realtimePriceVariationMin
+}
+
+// marketDraw returns the shared market move of the current tick.
+func (m *randomWalkModel) marketDraw(symbol string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A pair asking twice means a new tick started, draw a new market move
+	if len(m.consumed) == 0 || m.consumed[symbol] {
+		m.market = m.draw()
+		clear(m.consumed)
+	}
+	m.consumed[symbol] = true
+
+	return m.market
+}
+
+// newPriceModel builds the price model selected in the configuration, stepping once per tick
+// of simulated time.
+func newPriceModel(cfg *config.Config, tick time.Duration, logger *slog.Logger) (PriceModel, error) {
+	simple := newRandomWalkModel(cfg.MarketCorrelation, logger)
+	if cfg.PriceModel != config.PriceModelGBM {
+		return simple, nil
+	}
+	return newGBMModel(cfg.GBM, tick, simple, logger)
+}
+
+// gbmModel is a geometric Brownian motion driven by correlated shocks, so assets move
+// jointly (e.g. BTC and ETH together) the way the configured correlation matrix says.
+// Each tick draws one correlated shock vector, every pair consumes its own component.
+type gbmModel struct {
+	mu       sync.Mutex
+	index    map[string]int // Symbol to position in the vectors below.
+	drift    []float64      // Per-tick drift term, (mu - sigma^2/2) * dt.
+	scale    []float64      // Per-tick shock scale, sigma * sqrt(dt).
+	cholesky [][]float64    // Lower triangular factor of the correlation matrix.
+	shocks   []float64      // Current correlated shock vector.
+	consumed []bool         // Which pairs already used the current shocks.
+	fallback PriceModel     // Used for pairs that aren't part of the model.
+	logger   *slog.Logger
+}
+
+func newGBMModel(cfg *config.GBMConfig, tick time.Duration, fallback PriceModel, logger *slog.Logger) (*gbmModel, error) {
+	chol, err := choleskyDecompose(cfg.Correlation)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(cfg.Assets)
+	dt := tick.Seconds() * cfg.TimeScale / secondsPerYear
+	m := &gbmModel{
+		index:    make(map[string]int, n),
+		drift:    make([]float64, n),
+		scale:    make([]float64, n),
+		cholesky: chol,
+		consumed: make([]bool, n),
+		fallback: fallback,
+		logger:   logger,
+	}
+	for i, asset := range cfg.Assets {
+		m.index[asset.Symbol] = i
+		m.drift[i] = (asset.Drift - gbmDriftFactor*asset.Volatility*asset.Volatility) * dt
+		m.scale[i] = asset.Volatility * math.Sqrt(dt)
+	}
+
+	return m, nil
+}
+
+func (m *gbmModel) NextReturn(symbol string) float64 {
+	i, ok := m.index[symbol]
+	if !ok {
+		return m.fallback.NextReturn(symbol)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A pair asking twice means a new tick started, step all assets together
+	if m.shocks == nil || m.consumed[i] {
+		m.drawShocks()
+	}
+	m.consumed[i] = true
+
+	return math.Exp(m.drift[i]+m.scale[i]*m.shocks[i]) - 1
+}
+
+// drawShocks draws independent standard normals and correlates them through the Cholesky factor.
+func (m *gbmModel) drawShocks() {
+	n := len(m.cholesky)
+	independent := make([]float64, n)
+	for i := range independent {
+		independent[i] = m.standardNormal()
+	}
+
+	m.shocks = make([]float64, n)
+	for i := range n {
+		for j := 0; j <= i; j++ {
+			m.shocks[i] += m.cholesky[i][j] * independent[j]
+		}
+		m.consumed[i] = false
+	}
+}
+
+// standardNormal draws from N(0,1) using the Box-Muller transform.
+func (m *gbmModel) standardNormal() float64 {
+	u1 := 1 - secureFloat64(m.logger) // (0, 1], keeps the logarithm finite
+	u2 := secureFloat64(m.logger)
+	return math.Sqrt(boxMullerScale*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+}
+
+// choleskyDecompose returns L with L*L^T equal to the given symmetric matrix.
+// It fails if the matrix is not positive definite and so can't be a correlation matrix.
+func choleskyDecompose(matrix [][]float64) ([][]float64, error) {
+	n := len(matrix)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+
+	for i := range n {
+		for j := 0; j <= i; j++ {
+			sum := matrix[i][j]
+			for k := range j {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, fmt.Errorf("%w: not positive definite at row %d", ErrInvalidCorrelation, i)
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+
+	return l, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+)
+
+func TestPriceTickFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		candleInterval time.Duration
+		want           time.Duration
+	}{
+		{"minute candles use the default tick", time.Minute, 500 * time.Millisecond},
+		{"sub-second candles tick faster", 500 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceTickFor(tt.candleInterval); got != tt.want {
+				t.Errorf("priceTickFor(%v) = %v, want %v", tt.candleInterval, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGBMModelScalesToTick(t *testing.T) {
+	cfg := &config.GBMConfig{
+		Assets:      []config.GBMAsset{{Symbol: "BTCUSDT", Drift: 0.05, Volatility: 0.6}},
+		Correlation: [][]float64{{1}},
+		TimeScale:   1,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tick := range []time.Duration{100 * time.Millisecond, 500 * time.Millisecond} {
+		m, err := newGBMModel(cfg, tick, nil, logger)
+		if err != nil {
+			t.Fatalf("newGBMModel: %v", err)
+		}
+		dt := tick.Seconds() / secondsPerYear
+		if want := 0.6 * math.Sqrt(dt); math.Abs(m.scale[0]-want) > 1e-15 {
+			t.Errorf("tick %v: scale = %g, want %g", tick, m.scale[0], want)
+		}
+		if want := (0.05 - 0.5*0.6*0.6) * dt; math.Abs(m.drift[0]-want) > 1e-15 {
+			t.Errorf("tick %v: drift = %g, want %g", tick, m.drift[0], want)
+		}
+	}
+}
+
+// sampleCorrelation returns the Pearson correlation of xs and ys.
+func sampleCorrelation(xs, ys []float64) float64 {
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+
+	var cov, vx, vy float64
+	for i := range xs {
+		cov += (xs[i] - mx) * (ys[i] - my)
+		vx += (xs[i] - mx) * (xs[i] - mx)
+		vy += (ys[i] - my) * (ys[i] - my)
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+// sampleVariance returns the population variance of xs.
+func sampleVariance(xs []float64) float64 {
+	var mean, sq float64
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	return sq / float64(len(xs))
+}
+
+func TestRandomWalkCorrelation(t *testing.T) {
+	const ticks = 20000
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, rho := range []float64{0, 0.5, 1} {
+		m := newRandomWalkModel(rho, logger)
+		btc := make([]float64, ticks)
+		eth := make([]float64, ticks)
+		for i := range ticks {
+			btc[i] = m.NextReturn("BTCUSDT")
+			eth[i] = m.NextReturn("ETHUSDT")
+		}
+
+		if got := sampleCorrelation(btc, eth); math.Abs(got-rho) > 0.05 {
+			t.Errorf("rho %g: sample correlation %.3f", rho, got)
+		}
+		// Blending keeps the variance of a single uniform draw within ±0.2%, a²/3
+		want := realtimePriceVariationMin * realtimePriceVariationMin / 3
+		if got := sampleVariance(btc); math.Abs(got/want-1) > 0.05 {
+			t.Errorf("rho %g: variance %g, want %g", rho, got, want)
+		}
+	}
+}
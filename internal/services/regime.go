@@ -0,0 +1,68 @@
+package services
+
+import (
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// Volatility regimes a pair can be in.
+const (
+	RegimeCalm     = "calm"
+	RegimeVolatile = "volatile"
+)
+
+// stepRegime lets the pair possibly switch regime and returns the variation multiplier
+// for the current tick. Must be called with the pair write lock held.
+func (s *DataService) stepRegime(pair *models.TradingPair) float64 {
+	if !s.regime.Enabled {
+		return 1
+	}
+
+	switch pair.Regime {
+	case RegimeVolatile:
+		if secureFloat64(s.logger) < s.regime.VolatileToCalmProbability {
+			pair.Regime = RegimeCalm
+			s.logger.Info("Pair entered calm regime", "symbol", pair.Symbol)
+		}
+	default:
+		if secureFloat64(s.logger) < s.regime.CalmToVolatileProbability {
+			pair.Regime = RegimeVolatile
+			s.logger.Info("Pair entered volatile regime", "symbol", pair.Symbol)
+		}
+	}
+
+	return s.regimeMultiplier(pair.Regime)
+}
+
+// regimeMultiplier returns the variation multiplier of a regime.
+func (s *DataService) regimeMultiplier(regime string) float64 {
+	if !s.regime.Enabled {
+		return 1
+	}
+	if regime == RegimeVolatile {
+		return s.regime.VolatileMultiplier
+	}
+	return s.regime.CalmMultiplier
+}
+
+// DebugInfo returns simulation internals of a pair for troubleshooting.
+func (s *DataService) DebugInfo(symbol string) (map[string]any, error) {
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+
+	return map[string]any{
+		"symbol":           pair.Symbol,
+		"lastPrice":        pair.LastPrice,
+		"markPrice":        pair.MarkPrice,
+		"volatility":       pair.Volatility,
+		"regimeSwitching":  s.regime.Enabled,
+		"regime":           pair.Regime,
+		"regimeMultiplier": s.regimeMultiplier(pair.Regime),
+		"subscribers":      len(pair.Subscribers),
+		"candles":          len(pair.CandleData),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// maxRenkoBricks bounds the bricks of one chart, a tiny box size would otherwise produce
+// millions of them.
+const maxRenkoBricks = 10000
+
+// Directions of Renko bricks.
+const (
+	BrickUp   = "up"
+	BrickDown = "down"
+)
+
+// RenkoBrick is one box of a Renko chart.
+type RenkoBrick struct {
+	Time      int64   `json:"time"` // Synthetic, see RenkoBricks.
+	Open      float64 `json:"open"`
+	Close     float64 `json:"close"`
+	Direction string  `json:"direction"` // One of the Brick constants.
+}
+
+// Renko converts the candles of a pair into Renko bricks. The box size is boxSize, or the
+// average true range over atrPeriod candles when atrPeriod is positive.
+func (s *DataService) Renko(
+	ctx context.Context,
+	symbol string,
+	interval time.Duration,
+	boxSize float64,
+	atrPeriod int,
+) (map[string]any, error) {
+	candles, err := s.GetCandleDataForInterval(ctx, symbol, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{"symbol": s.resolveSymbol(symbol)}
+	if atrPeriod > 0 {
+		if boxSize = AverageTrueRange(candles, atrPeriod); boxSize <= 0 {
+			return nil, ErrInsufficientHistory
+		}
+		result["atrPeriod"] = atrPeriod
+	}
+	bricks, err := RenkoBricks(candles, boxSize)
+	if err != nil {
+		return nil, err
+	}
+	result["boxSize"] = boxSize
+	result["bricks"] = bricks
+	return result, nil
+}
+
+// RenkoBricks builds Renko bricks of boxSize from candle closes, starting at the first
+// close. A brick in the direction of the last one needs a move of one box past it, a
+// reversal a move of one box past its open. Bricks completed by the same candle get that
+// candle's time plus their index in milliseconds, so brick times stay unique. Box sizes
+// producing more than maxRenkoBricks bricks fail with ErrTooManyBricks.
+func RenkoBricks(candles []models.CandleData, boxSize float64) ([]RenkoBrick, error) {
+	bricks := make([]RenkoBrick, 0)
+	if len(candles) == 0 || boxSize <= 0 {
+		return bricks, nil
+	}
+
+	// Top and bottom of the last brick, a zero height brick at the first close to start with
+	top, bottom := candles[0].Close, candles[0].Close
+	for _, candle := range candles[1:] {
+		// A candle adds about one brick per box between its close and the last brick
+		if math.Abs(candle.Close-top)/boxSize > float64(maxRenkoBricks-len(bricks)) {
+			return nil, ErrTooManyBricks
+		}
+
+		n := int64(0)
+		for candle.Close >= top+boxSize {
+			bricks = append(bricks, RenkoBrick{Time: candle.Time + n, Open: top, Close: top + boxSize, Direction: BrickUp})
+			bottom, top = top, top+boxSize
+			n++
+		}
+		for candle.Close <= bottom-boxSize {
+			bricks = append(bricks, RenkoBrick{Time: candle.Time + n, Open: bottom, Close: bottom - boxSize, Direction: BrickDown})
+			top, bottom = bottom, bottom-boxSize
+			n++
+		}
+	}
+	return bricks, nil
+}
+
+// AverageTrueRange returns Wilder's average true range over period candles at the end of
+// the series, or 0 when there are not more than period candles.
+func AverageTrueRange(candles []models.CandleData, period int) float64 {
+	if period < 1 || len(candles) <= period {
+		return 0
+	}
+
+	var atr float64
+	for i := 1; i < len(candles); i++ {
+		prevClose := candles[i-1].Close
+		trueRange := math.Max(candles[i].High-candles[i].Low,
+			math.Max(math.Abs(candles[i].High-prevClose), math.Abs(candles[i].Low-prevClose)))
+
+		switch {
+		case i <= period:
+			// The first value is the plain mean of the first period true ranges
+			atr += trueRange / float64(period)
+		default:
+			atr = (atr*float64(period-1) + trueRange) / float64(period)
+		}
+	}
+	return atr
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"time"
+)
+
+// simClock is the clock of the simulation. At a speed above 1 simulated time runs ahead
+// of the wall clock, starting from the moment the clock was created. Away from real speed,
+// or when asked to be monotonic, it advances by the monotonic clock, so stepping the system
+// clock (NTP corrections, manual changes) doesn't move candle times.
+type simClock struct {
+	origin    time.Time
+	speed     float64
+	monotonic bool
+	wall      func() time.Time // Reads the wall clock, time.Now outside tests.
+}
+
+// newSimClock returns a clock starting at the current time and running speed times faster.
+func newSimClock(speed float64, monotonic bool) simClock {
+	return simClock{origin: time.Now(), speed: speed, monotonic: monotonic, wall: time.Now}
+}
+
+// Now returns the current simulated time.
+func (c simClock) Now() time.Time {
+	if c.speed == 1 && !c.monotonic {
+		return c.wall()
+	}
+	elapsed := c.wall().Sub(c.origin)
+	// Round(0) strips the monotonic reading, callers compare and format wall times
+	return c.origin.Add(time.Duration(float64(elapsed) * c.speed)).Round(0)
+}
+
+// Real converts a span of simulated time into the wall clock time it takes to pass.
+func (c simClock) Real(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
+
+// Now returns the current simulated time, candle times are based on it.
+func (s *DataService) Now() time.Time {
+	return s.clock.Now()
+}
+
+// Speed returns how much faster than real time the simulation runs.
+func (s *DataService) Speed() float64 {
+	return s.clock.speed
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// day is the unit of "d" windows.
+const day = 24 * time.Hour
+
+// indexSourceMark tells clients that the index price is the simulated mark price.
+const indexSourceMark = "mark"
+
+// Stats returns a summary of the simulation: per-pair activity and subscriber counts. With a
+// positive window each pair also reports its percent price change over that lookback.
+func (s *DataService) Stats(ctx context.Context, window time.Duration) (map[string]any, error) {
+	pairs := s.Pairs()
+	since := s.clock.Now().Add(-window)
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Symbol < pairs[j].Symbol })
+
+	pairStats := make([]map[string]any, 0, len(pairs))
+	totalSubscribers := 0
+	for _, pair := range pairs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pair.Mutex.RLock()
+		subscribers := len(pair.Subscribers)
+		entry := map[string]any{
+			"symbol":      pair.Symbol,
+			"lastPrice":   pair.LastPrice,
+			"lastUpdate":  pair.LastUpdate.UnixMilli(),
+			"subscribers": subscribers,
+			"candles":     len(pair.CandleData),
+			"allTimeHigh": pair.AllTimeHigh,
+			"allTimeLow":  pair.AllTimeLow,
+		}
+		// Volume covers the retained history, or the window from its baseline candle on. Running
+		// totals are undone before cutting the window, so its first candle loses the total of
+		// the candle before it too
+		volumeCandles := s.perCandleVolumes(pair.CandleData)
+		if window > 0 {
+			if baseline, ok := closestCandle(pair.CandleData, since.UnixMilli()); ok {
+				entry["windowChange"] = (pair.LastPrice/baseline.Open - 1) * percentMultiplier
+				entry["windowBaseline"] = baseline.Time
+				volumeCandles = candlesFrom(volumeCandles, baseline.Time)
+			}
+		}
+		volume := summarizeVolume(volumeCandles)
+		entry["volume"] = volume.Volume
+		entry["quoteVolume"] = volume.QuoteVolume
+		entry["vwap"] = volume.VWAP
+		pairStats = append(pairStats, entry)
+		pair.Mutex.RUnlock()
+
+		totalSubscribers += subscribers
+	}
+
+	stats := map[string]any{
+		"pairs":       len(pairs),
+		"maxPairs":    s.maxPairs,
+		"subscribers": totalSubscribers,
+		"pairStats":   pairStats,
+	}
+	if window > 0 {
+		stats["window"] = FormatWindow(window)
+	}
+	return stats, nil
+}
+
+// closestCandle returns the candle whose start time is closest to t, the earlier one on a tie.
+// Candles must be sorted by time.
+func closestCandle(candles []models.CandleData, t int64) (models.CandleData, bool) {
+	if len(candles) == 0 {
+		return models.CandleData{}, false
+	}
+
+	i := sort.Search(len(candles), func(i int) bool { return candles[i].Time >= t })
+	switch {
+	case i == 0:
+		return candles[0], true
+	case i == len(candles):
+		return candles[i-1], true
+	case t-candles[i-1].Time <= candles[i].Time-t:
+		return candles[i-1], true
+	default:
+		return candles[i], true
+	}
+}
+
+// candlesFrom returns the candles starting at or after t. Candles must be sorted by time.
+func candlesFrom(candles []models.CandleData, t int64) []models.CandleData {
+	i := sort.Search(len(candles), func(i int) bool { return candles[i].Time >= t })
+	return candles[i:]
+}
+
+// ParseWindow parses a lookback window such as "4h" or "7d". Days are accepted on top of
+// the units of time.ParseDuration.
+func ParseWindow(text string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", text)
+		}
+		window = time.Duration(n) * day
+	} else {
+		var err error
+		if window, err = time.ParseDuration(text); err != nil {
+			return 0, fmt.Errorf("invalid window %q", text)
+		}
+	}
+
+	if window <= 0 {
+		return 0, fmt.Errorf("window must be positive, got %q", text)
+	}
+	return window, nil
+}
+
+// FormatWindow renders a window the way ParseWindow reads it, whole days as e.g. "7d".
+func FormatWindow(window time.Duration) string {
+	if window%day == 0 {
+		return strconv.Itoa(int(window/day)) + "d"
+	}
+	return FormatInterval(window)
+}
+
+// StalePairs returns the symbols of pairs that haven't been updated within maxAge,
+// which means their simulation stalled.
+func (s *DataService) StalePairs(maxAge time.Duration) []string {
+	deadline := time.Now().Add(-maxAge)
+
+	var stale []string
+	for _, pair := range s.Pairs() {
+		pair.Mutex.RLock()
+		if pair.LastUpdate.Before(deadline) {
+			stale = append(stale, pair.Symbol)
+		}
+		pair.Mutex.RUnlock()
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// IndexPrice returns the reference price of a pair used for settlement. The server only
+// simulates prices, so the index is the pair's mark price rather than a median of feeds.
+func (s *DataService) IndexPrice(ctx context.Context, symbol string) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.getPair(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pair.Mutex.RLock()
+	defer pair.Mutex.RUnlock()
+
+	return map[string]any{
+		"symbol":     pair.Symbol,
+		"indexPrice": pair.MarkPrice,
+		"source":     indexSourceMark,
+		"time":       pair.LastUpdate.UnixMilli(),
+	}, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+func TestStatsWindowVolume(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		volumes []float64 // Stored candle volumes, one minute apart.
+		window  time.Duration
+		want    float64
+	}{
+		{"per candle, whole history", config.VolumeModeCandle, []float64{10, 10, 10, 10, 10}, 0, 50},
+		{"per candle, window", config.VolumeModeCandle, []float64{10, 10, 10, 10, 10}, 2 * time.Minute, 30},
+		{"cumulative, whole history", config.VolumeModeCumulative, []float64{10, 20, 30, 40, 50}, 0, 50},
+		{"cumulative, window", config.VolumeModeCumulative, []float64{10, 20, 30, 40, 50}, 2 * time.Minute, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, func(cfg *config.Config) {
+				cfg.VolumeMode = tt.mode
+				cfg.CandleInterval = time.Minute
+				cfg.CandleAlignment = config.CandleAlignmentUTC
+			})
+			// A clock stopped at midday, so the candles share a session
+			now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			useFakeClock(s, now)
+
+			pair := NewTradingPair("TESTUSDT", 100)
+			for i, volume := range tt.volumes {
+				at := now.Add(time.Duration(i-len(tt.volumes)+1) * time.Minute)
+				pair.CandleData = append(pair.CandleData, models.CandleData{
+					Time: at.UnixMilli(), Open: 100, High: 100, Low: 100, Close: 100, Volume: volume,
+				})
+			}
+			s.pairsMu.Lock()
+			s.pairs[pair.Symbol] = pair
+			s.pairsMu.Unlock()
+
+			stats, err := s.Stats(context.Background(), tt.window)
+			if err != nil {
+				t.Fatalf("Stats: %v", err)
+			}
+			entry := stats["pairStats"].([]map[string]any)[0]
+			if got := entry["volume"].(float64); got != tt.want {
+				t.Errorf("volume = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStalePairs(t *testing.T) {
+	s := newTestService(t, nil)
+	addIdlePair(t, s, "FRESHUSDT")
+	for _, symbol := range []string{"OLDUSDT", "ALSOOLDUSDT"} {
+		pair := addIdlePair(t, s, symbol)
+		pair.LastUpdate = time.Now().Add(-time.Hour)
+	}
+
+	if got, want := s.StalePairs(time.Minute), []string{"ALSOOLDUSDT", "OLDUSDT"}; !slices.Equal(got, want) {
+		t.Errorf("stale pairs %v, want %v", got, want)
+	}
+	if got := s.StalePairs(2 * time.Hour); len(got) != 0 {
+		t.Errorf("stale pairs %v, want none", got)
+	}
+}
+
+func TestClosestCandle(t *testing.T) {
+	candles := []models.CandleData{{Time: 1000}, {Time: 2000}, {Time: 3000}}
+
+	tests := []struct {
+		name    string
+		candles []models.CandleData
+		t       int64
+		want    int64
+		wantOK  bool
+	}{
+		{"no candles", nil, 1000, 0, false},
+		{"before the first", candles, 0, 1000, true},
+		{"after the last", candles, 9000, 3000, true},
+		{"exact", candles, 2000, 2000, true},
+		{"nearer the earlier", candles, 2400, 2000, true},
+		{"nearer the later", candles, 2600, 3000, true},
+		{"tie takes the earlier", candles, 2500, 2000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := closestCandle(tt.candles, tt.t)
+			if ok != tt.wantOK || got.Time != tt.want {
+				t.Fatalf("closestCandle(%d) = %d, %v, want %d, %v", tt.t, got.Time, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{text: "4h", want: 4 * time.Hour},
+		{text: "90m", want: 90 * time.Minute},
+		{text: "7d", want: 7 * day},
+		{text: "1d", want: day},
+		{text: "0d", wantErr: true},
+		{text: "-1h", wantErr: true},
+		{text: "d", wantErr: true},
+		{text: "1.5d", wantErr: true},
+		{text: "week", wantErr: true},
+		{text: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseWindow(tt.text)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("ParseWindow(%q) = %v, %v, want %v, error %v", tt.text, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   string
+	}{
+		{day, "1d"},
+		{7 * day, "7d"},
+		{4 * time.Hour, "4h"},
+		{36 * time.Hour, "36h"},
+		{15 * time.Minute, "15m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatWindow(tt.window)
+			if got != tt.want {
+				t.Fatalf("FormatWindow(%v) = %q, want %q", tt.window, got, tt.want)
+			}
+			if back, err := ParseWindow(got); err != nil || back != tt.window {
+				t.Errorf("ParseWindow(%q) = %v, %v, want %v", got, back, err, tt.window)
+			}
+		})
+	}
+}
+
+func TestStatsWindowChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		window       time.Duration
+		wantBaseline int // Index of the baseline candle, -1 when no window is reported.
+		wantChange   float64
+	}{
+		{"no window", 0, -1, 0},
+		{"one candle back", time.Minute, 3, 25},
+		{"between candles takes the closest", 130 * time.Second, 2, 100.0/60*100 - 100},
+		{"tie takes the earlier", 150 * time.Second, 1, 150},
+		{"longer than the history", time.Hour, 0, 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, nil)
+			now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			useFakeClock(s, now)
+
+			// Opens of 20, 40, 60, 80 one minute apart, the last starting a minute ago
+			pair := NewTradingPair("TESTUSDT", 100)
+			for i := range 4 {
+				open := float64(20 * (i + 1))
+				pair.CandleData = append(pair.CandleData, models.CandleData{
+					Time: now.Add(time.Duration(i-4) * time.Minute).UnixMilli(), Open: open, High: open, Low: open, Close: open,
+				})
+			}
+			pair.LastPrice = 100
+			s.pairsMu.Lock()
+			s.pairs[pair.Symbol] = pair
+			s.pairsMu.Unlock()
+
+			stats, err := s.Stats(context.Background(), tt.window)
+			if err != nil {
+				t.Fatalf("Stats: %v", err)
+			}
+			entry := stats["pairStats"].([]map[string]any)[0]
+			if tt.wantBaseline < 0 {
+				if _, ok := entry["windowChange"]; ok {
+					t.Errorf("windowChange reported without a window: %v", entry)
+				}
+				if _, ok := stats["window"]; ok {
+					t.Errorf("window reported without a window: %v", stats["window"])
+				}
+				return
+			}
+			if got, want := entry["windowBaseline"], pair.CandleData[tt.wantBaseline].Time; got != want {
+				t.Errorf("windowBaseline = %v, want %v", got, want)
+			}
+			if got := entry["windowChange"].(float64); got < tt.wantChange-1e-9 || got > tt.wantChange+1e-9 {
+				t.Errorf("windowChange = %v, want %v", got, tt.wantChange)
+			}
+			if got := stats["window"]; got != FormatWindow(tt.window) {
+				t.Errorf("window = %v, want %v", got, FormatWindow(tt.window))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+	"github.com/sand/crypto-trading-app/backend/internal/websocket"
+)
+
+// RunSubscriberReaper periodically pings every subscriber and drops those that fail the ping
+// or have been silent for longer than idleTimeout. It returns when ctx is cancelled.
+func (s *DataService) RunSubscriberReaper(ctx context.Context, interval, idleTimeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, pair := range s.Pairs() {
+				s.reapSubscribers(pair, idleTimeout)
+			}
+		}
+	}
+}
+
+// reapSubscribers removes dead subscribers of a single pair.
+func (s *DataService) reapSubscribers(pair *models.TradingPair, idleTimeout time.Duration) {
+	// Collect subscribers first so pings are not sent while holding the pair lock
+	pair.Mutex.RLock()
+	subs := make([]*websocket.Subscriber, 0, len(pair.Subscribers))
+	for sub := range pair.Subscribers {
+		subs = append(subs, sub)
+	}
+	// A kline subscriber may follow several intervals of the pair and is collected once
+	seen := maps.Clone(pair.Subscribers)
+	for _, klineSubscribers := range pair.KlineSubscribers {
+		for sub := range klineSubscribers {
+			if !seen[sub] {
+				seen[sub] = true
+				subs = append(subs, sub)
+			}
+		}
+	}
+	pair.Mutex.RUnlock()
+
+	deadline := time.Now().Add(-idleTimeout)
+	for _, sub := range subs {
+		if sub.LastActivity().Before(deadline) {
+			s.logger.Info("Reaping idle subscriber", "conn", sub.ID(), "symbol", pair.Symbol, "lastActivity", sub.LastActivity())
+			s.dropSubscriber(pair, sub)
+			continue
+		}
+
+		if err := sub.Ping(); err != nil {
+			s.logger.Info("Reaping unresponsive subscriber", "conn", sub.ID(), "symbol", pair.Symbol, "error", err)
+			s.dropSubscriber(pair, sub)
+		}
+	}
+}
+
+// dropSubscriber removes a subscriber from the pair and closes its connection.
+func (s *DataService) dropSubscriber(pair *models.TradingPair, sub *websocket.Subscriber) {
+	pair.Mutex.Lock()
+	delete(pair.Subscribers, sub)
+	for _, klineSubscribers := range pair.KlineSubscribers {
+		delete(klineSubscribers, sub)
+	}
+	pair.Mutex.Unlock()
+
+	if err := sub.Close(); err != nil {
+		s.logger.Debug("Error closing reaped subscriber", "conn", sub.ID(), "symbol", pair.Symbol, "error", err)
+	}
+}
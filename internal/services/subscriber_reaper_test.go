@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReapSubscribers(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleTimeout time.Duration
+		closeClient bool
+		wantReaped  bool
+	}{
+		{name: "active and responsive", idleTimeout: time.Hour, wantReaped: false},
+		{name: "idle", idleTimeout: 0, wantReaped: true},
+		{name: "connection gone", idleTimeout: time.Hour, closeClient: true, wantReaped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, nil)
+			pair := addIdlePair(t, s, "TESTUSDT")
+			sub, client := newDialer(t, nil)()
+			if err := s.AddSubscriber(context.Background(), pair.Symbol, sub); err != nil {
+				t.Fatalf("subscribing: %v", err)
+			}
+			if tt.closeClient {
+				client.Close()
+				// The server notices on its next read and closes its end
+				for deadline := time.Now().Add(time.Second); sub.Ping() == nil && time.Now().Before(deadline); {
+					time.Sleep(10 * time.Millisecond)
+				}
+			}
+
+			s.reapSubscribers(pair, tt.idleTimeout)
+
+			pair.Mutex.RLock()
+			subscribed := pair.Subscribers[sub]
+			pair.Mutex.RUnlock()
+			if subscribed == tt.wantReaped {
+				t.Fatalf("still subscribed = %v, want reaped %v", subscribed, tt.wantReaped)
+			}
+			if tt.wantReaped && !tt.closeClient {
+				// The reaped connection is closed, the client's next read fails
+				_ = client.SetReadDeadline(time.Now().Add(time.Second))
+				for {
+					if _, _, err := client.ReadMessage(); err != nil {
+						break
+					}
+				}
+				if sub.Ping() == nil {
+					t.Error("reaped connection still open")
+				}
+			}
+		})
+	}
+}
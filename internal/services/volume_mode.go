@@ -0,0 +1,45 @@
+package services
+
+import (
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// VolumeMode returns how candle volume is counted, one of the config.VolumeMode constants.
+func (s *DataService) VolumeMode() string {
+	return s.volumeMode
+}
+
+// carriedVolume returns the volume a candle starting at t takes over from the candle before
+// it: the session total so far in cumulative mode, nothing per candle or in a new session.
+func (s *DataService) carriedVolume(prev models.CandleData, t time.Time) float64 {
+	if s.volumeMode != config.VolumeModeCumulative || !s.sameSession(time.UnixMilli(prev.Time), t) {
+		return 0
+	}
+	return prev.Volume
+}
+
+// sameSession reports whether a and b fall on the same session, the day candles are aligned to.
+func (s *DataService) sameSession(a, b time.Time) bool {
+	return s.alignTime(a, day).Equal(s.alignTime(b, day))
+}
+
+// perCandleVolumes returns the candles with the volume traded within each of them, undoing
+// the running totals of cumulative mode; per-candle volumes are returned as they are. The
+// first candle has no predecessor and keeps its volume.
+func (s *DataService) perCandleVolumes(candles []models.CandleData) []models.CandleData {
+	if s.volumeMode != config.VolumeModeCumulative {
+		return candles
+	}
+
+	result := make([]models.CandleData, len(candles))
+	copy(result, candles)
+	for i := 1; i < len(candles); i++ {
+		if s.sameSession(time.UnixMilli(candles[i-1].Time), time.UnixMilli(candles[i].Time)) {
+			result[i].Volume -= candles[i-1].Volume
+		}
+	}
+	return result
+}
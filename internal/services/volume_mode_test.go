@@ -0,0 +1,128 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// volumeModeService returns a service counting volume in mode, with one minute candles
+// aligned to UTC so sessions end at UTC midnight.
+func volumeModeService(t *testing.T, mode string) *DataService {
+	t.Helper()
+
+	return newTestService(t, func(cfg *config.Config) {
+		cfg.VolumeMode = mode
+		cfg.CandleInterval = time.Minute
+		cfg.CandleAlignment = config.CandleAlignmentUTC
+	})
+}
+
+func TestCarriedVolume(t *testing.T) {
+	midday := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	lastOfDay := time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		mode string
+		prev time.Time
+		t    time.Time
+		want float64
+	}{
+		{"per candle", config.VolumeModeCandle, midday, midday.Add(time.Minute), 0},
+		{"cumulative within a session", config.VolumeModeCumulative, midday, midday.Add(time.Minute), 40},
+		{"cumulative across midnight", config.VolumeModeCumulative, lastOfDay, lastOfDay.Add(time.Minute), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := volumeModeService(t, tt.mode)
+			prev := models.CandleData{Time: tt.prev.UnixMilli(), Volume: 40}
+			if got := s.carriedVolume(prev, tt.t); got != tt.want {
+				t.Errorf("carriedVolume = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerCandleVolumes(t *testing.T) {
+	// Two candles before UTC midnight and two after
+	start := time.Date(2026, 1, 1, 23, 58, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		mode   string
+		stored []float64
+		want   []float64
+	}{
+		{"per candle kept", config.VolumeModeCandle, []float64{10, 20, 30, 40}, []float64{10, 20, 30, 40}},
+		{"running totals undone", config.VolumeModeCumulative, []float64{10, 30, 5, 12}, []float64{10, 20, 5, 7}},
+		{"empty", config.VolumeModeCumulative, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := volumeModeService(t, tt.mode)
+			candles := make([]models.CandleData, len(tt.stored))
+			for i, volume := range tt.stored {
+				candles[i] = models.CandleData{Time: start.Add(time.Duration(i) * time.Minute).UnixMilli(), Volume: volume}
+			}
+
+			result := s.perCandleVolumes(candles)
+			var got []float64
+			for _, candle := range result {
+				got = append(got, candle.Volume)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("volumes = %v, want %v", got, tt.want)
+			}
+			for i, volume := range tt.stored {
+				if candles[i].Volume != volume {
+					t.Fatalf("stored candles changed: %+v", candles)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateHistoryCumulativeVolume(t *testing.T) {
+	s := volumeModeService(t, config.VolumeModeCumulative)
+	// A clock stopped at 02:00 UTC, the 288 minutes of history cross midnight once
+	useFakeClock(s, time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC))
+	candles := s.generateHistory(100)
+
+	resets := 0
+	for i := 1; i < len(candles); i++ {
+		prev, candle := candles[i-1], candles[i]
+		if s.sameSession(time.UnixMilli(prev.Time), time.UnixMilli(candle.Time)) {
+			if candle.Volume <= prev.Volume {
+				t.Fatalf("running total falls from %v to %v within a session", prev.Volume, candle.Volume)
+			}
+			continue
+		}
+		resets++
+		if candle.Volume >= prev.Volume {
+			t.Errorf("session at %v starts from %v, want the total reset", time.UnixMilli(candle.Time).UTC(), candle.Volume)
+		}
+	}
+	if resets != 1 {
+		t.Errorf("got %d session resets, want one at midnight", resets)
+	}
+}
+
+func TestAggregateCandlesCumulativeVolume(t *testing.T) {
+	s := volumeModeService(t, config.VolumeModeCumulative)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	candles := minuteCandles(start, 100, 101, 102, 103, 104, 105, 106)
+	for i := range candles {
+		candles[i].Volume = float64(10 * (i + 1)) // Running total of 10 per minute
+	}
+
+	got := s.aggregateCandles(candles, 5*time.Minute)
+	if len(got) != 2 || got[0].Volume != 50 || got[1].Volume != 70 {
+		t.Errorf("got %+v, want the running totals 50 and 70 at the end of each bucket", got)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"math"
+
+	"github.com/sand/crypto-trading-app/backend/internal/models"
+)
+
+// volumeSummary totals the traded volume of a run of candles.
+type volumeSummary struct {
+	Volume      float64 // Base asset volume.
+	QuoteVolume float64 // Quote asset volume, the sum of close * volume.
+	VWAP        float64 // Volume-weighted average price, 0 without volume.
+}
+
+// summarizeVolume computes the volume totals and VWAP of the candles. The sums are
+// compensated (Neumaier), so the small candles of a long run aren't lost next to the
+// large ones of high-priced pairs.
+func summarizeVolume(candles []models.CandleData) volumeSummary {
+	var volume, quoteVolume compensatedSum
+	for _, candle := range candles {
+		volume.add(candle.Volume)
+		quoteVolume.add(candle.Close * candle.Volume)
+	}
+
+	summary := volumeSummary{Volume: volume.value(), QuoteVolume: quoteVolume.value()}
+	if summary.Volume > 0 {
+		summary.VWAP = summary.QuoteVolume / summary.Volume
+	}
+	return summary
+}
+
+// compensatedSum is a running sum that carries the rounding error of each addition.
+type compensatedSum struct {
+	sum          float64
+	compensation float64
+}
+
+func (k *compensatedSum) add(x float64) {
+	t := k.sum + x
+	if math.Abs(k.sum) >= math.Abs(x) {
+		k.compensation += (k.sum - t) + x
+	} else {
+		k.compensation += (x - t) + k.sum
+	}
+	k.sum = t
+}
+
+func (k *compensatedSum) value() float64 {
+	return k.sum + k.compensation
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"slices"
+	"time"
+)
+
+// Reasons an update is dropped, used as metric labels.
+const (
+	dropReasonOldest       = "dropped_oldest" // Evicted from the queue by a newer update.
+	dropReasonQueueFull    = "queue_full"     // Discarded while waiting out a backlog.
+	dropReasonBlockTimeout = "block_timeout"  // No room appeared within the block timeout.
+	dropReasonSlowConsumer = "slow_consumer"  // Still queued when the client was disconnected.
+)
+
+// Reasons a slow client is disconnected, used as metric labels.
+const (
+	disconnectReasonBacklog  = "backlog"          // Queue stayed full for the backlog timeout.
+	disconnectReasonFullRuns = "consecutive_full" // Queue was full for too many broadcasts in a row.
+)
+
+// CloseSlowConsumer is the close code sent to clients disconnected for not keeping up.
+// It is in the range reserved for applications.
+const CloseSlowConsumer = 4008
+
+// sendDroppingOldest makes room by discarding the oldest queued update, so a lagging
+// client skips stale ticks but always receives the latest prices. Only updates that a later
+// one supersedes are evicted: control frames, snapshots and closed bars stay queued in
+// order, and when nothing else is queued the new message is dropped instead. The caller
+// holds queueMu, so the write pump is the only other user of the queue and the frames put
+// back always fit.
+func (s *Subscriber) sendDroppingOldest(msg outgoing) bool {
+	queued := s.takeQueued(s.evicting[:0])
+	defer func() {
+		clear(queued)
+		s.evicting = queued[:0]
+	}()
+
+	sent := true
+	if len(queued) == cap(s.send) {
+		// The write pump took nothing meanwhile, so an update has to go
+		oldest := slices.IndexFunc(queued, func(m outgoing) bool { return !m.control })
+		if oldest >= 0 {
+			s.dropped(queued[oldest], dropReasonOldest)
+			queued = slices.Delete(queued, oldest, oldest+1)
+		} else {
+			s.dropped(msg, dropReasonQueueFull)
+			sent = false
+		}
+	}
+	if sent {
+		queued = append(queued, msg)
+	}
+
+	for _, queuedMsg := range queued {
+		s.send <- queuedMsg
+	}
+	return sent
+}
+
+// takeQueued moves the queued messages to buf, oldest first, and returns it.
+func (s *Subscriber) takeQueued(buf []outgoing) []outgoing {
+	for len(buf) < cap(s.send) {
+		select {
+		case msg := <-s.send:
+			buf = append(buf, msg)
+		default:
+			return buf
+		}
+	}
+	return buf
+}
+
+// sendBlocking waits for room in the queue up to the block timeout. The broadcaster is held
+// up meanwhile, so the timeout should stay well below the price tick.
+func (s *Subscriber) sendBlocking(msg outgoing) bool {
+	timer := time.NewTimer(s.delivery.BlockTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-s.done:
+		return false
+	case s.send <- msg:
+		return true
+	case <-timer.C:
+		s.dropped(msg, dropReasonBlockTimeout)
+		return false
+	}
+}
+
+// disconnectIfBacklogged drops the update and closes the connection once the queue has
+// been full for longer than the backlog timeout. The read loop then removes the subscriber.
+func (s *Subscriber) disconnectIfBacklogged(msg outgoing) {
+	s.dropped(msg, dropReasonQueueFull)
+
+	now := time.Now().UnixNano()
+	s.fullSince.CompareAndSwap(0, now)
+	backlog := time.Duration(now - s.fullSince.Load())
+	if backlog < s.delivery.BacklogTimeout {
+		return
+	}
+
+	s.disconnectSlow(disconnectReasonBacklog, "backlog", backlog)
+}
+
+// countFullQueue records a broadcast that found the queue full and disconnects the client
+// once that happened for the slow consumer threshold of broadcasts in a row.
+func (s *Subscriber) countFullQueue() {
+	runs := s.fullRuns.Add(1)
+	if threshold := s.delivery.SlowConsumerThreshold; threshold > 0 && runs >= int64(threshold) {
+		s.disconnectSlow(disconnectReasonFullRuns, "consecutiveFull", runs)
+	}
+}
+
+// disconnectSlow closes a lagging client with CloseSlowConsumer. The close runs in the
+// background because the write pump may be stuck writing to this client, and the caller
+// is a broadcast that must not wait for it. The read loop then removes the subscriber
+// from every pair.
+func (s *Subscriber) disconnectSlow(reason string, detailKey string, detail any) {
+	if !s.closing.CompareAndSwap(false, true) {
+		return // Already being closed by a concurrent broadcast
+	}
+
+	s.logger.Warn("Disconnecting slow WebSocket client", "reason", reason, detailKey, detail)
+	s.metrics.SlowConsumerDisconnected(reason)
+	go func() {
+		if err := s.CloseWithCode(CloseSlowConsumer, "too slow to keep up with updates"); err != nil {
+			s.logger.Debug("Error closing slow subscriber", "error", err)
+		}
+		s.discardQueue(dropReasonSlowConsumer)
+	}()
+}
+
+// discardQueue empties the send queue of a closed subscriber, counting what was lost.
+func (s *Subscriber) discardQueue(reason string) {
+	for {
+		select {
+		case msg := <-s.send:
+			s.dropped(msg, reason)
+		default:
+			return
+		}
+	}
+}
+
+// dropped records a message that did not reach the client.
+func (s *Subscriber) dropped(msg outgoing, reason string) {
+	s.logger.Debug("Dropped update for slow subscriber",
+		"symbol", msg.symbol, "channel", msg.channel, "interval", msg.interval, "reason", reason)
+	s.metrics.MessageDropped(msg.symbol, msg.channel, reason)
+}
@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+)
+
+func TestSlowConsumerDisconnectedWhileWriterIsStuck(t *testing.T) {
+	delivery := testDelivery()
+	delivery.Backpressure = config.BackpressureDisconnect
+	delivery.BacklogTimeout = 50 * time.Millisecond
+	sub, _ := connect(t, newTestManager(delivery))
+
+	// The client never reads, so once the socket buffers are full the write pump blocks
+	// inside a write and the queue backs up behind it
+	payload := strings.Repeat("x", 256<<10)
+	deadline := time.Now().Add(5 * time.Second)
+	for !sub.closing.Load() && time.Now().Before(deadline) {
+		sub.Send("BTCUSDT", payload)
+		time.Sleep(time.Millisecond)
+	}
+	if !sub.closing.Load() {
+		t.Fatal("slow consumer was never flagged")
+	}
+
+	waitClosed(t, sub, 3*time.Second)
+}
+
+func TestDropOldestKeepsControlFrames(t *testing.T) {
+	update := func(symbol string) outgoing {
+		return outgoing{symbol: symbol, channel: channelCandles, payload: symbol}
+	}
+	control := func(name string) outgoing {
+		return outgoing{channel: channelControl, payload: name, control: true}
+	}
+
+	tests := []struct {
+		name     string
+		queued   []outgoing
+		msg      outgoing
+		wantSent bool
+		want     []any // Payloads left in the queue, oldest first.
+	}{
+		{
+			name:     "oldest update evicted",
+			queued:   []outgoing{update("BTCUSDT"), update("ETHUSDT"), update("SOLUSDT")},
+			msg:      update("XRPUSDT"),
+			wantSent: true,
+			want:     []any{"ETHUSDT", "SOLUSDT", "XRPUSDT"},
+		},
+		{
+			name:     "control frames skipped",
+			queued:   []outgoing{control("ack"), update("BTCUSDT"), control("error")},
+			msg:      update("ETHUSDT"),
+			wantSent: true,
+			want:     []any{"ack", "error", "ETHUSDT"},
+		},
+		{
+			name:     "control frame makes room",
+			queued:   []outgoing{control("snapshot"), control("closed bar"), update("BTCUSDT")},
+			msg:      control("symbol_removed"),
+			wantSent: true,
+			want:     []any{"snapshot", "closed bar", "symbol_removed"},
+		},
+		{
+			name:     "only control frames queued",
+			queued:   []outgoing{control("ack"), control("error"), control("snapshot")},
+			msg:      update("BTCUSDT"),
+			wantSent: false,
+			want:     []any{"ack", "error", "snapshot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := testDelivery()
+			delivery.SendQueueSize = len(tt.queued)
+			sub := NewSubscriber(nil, delivery, metrics.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			for _, msg := range tt.queued {
+				sub.send <- msg
+			}
+
+			if sent := sub.enqueue(tt.msg); sent != tt.wantSent {
+				t.Errorf("enqueue = %v, want %v", sent, tt.wantSent)
+			}
+
+			var got []any
+			for _, msg := range sub.takeQueued(nil) {
+				got = append(got, msg.payload)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("queue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetBackpressureWhileSending(t *testing.T) {
+	sub := newTestSubscriber()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 1000 {
+			sub.Send("BTCUSDT", 1.0)
+		}
+	}()
+
+	// Policies switched under a running broadcast must not race with it, run with -race
+	for i := range 1000 {
+		if i%2 == 0 {
+			sub.SetBackpressure(config.BackpressureDisconnect)
+		} else {
+			sub.SetBackpressure(config.BackpressureDropOldest)
+		}
+	}
+	<-done
+}
+
+func TestMessageDroppedLabels(t *testing.T) {
+	hourly := Kline{Symbol: "BTCUSDT", Interval: time.Hour}
+
+	tests := []struct {
+		name   string
+		queued func(*Subscriber) bool
+		series string
+	}{
+		{
+			name:   "kline update",
+			queued: func(s *Subscriber) bool { return s.SendKline(hourly, map[string]any{"close": 1.5}) },
+			series: `websocket_messages_dropped_total{channel="kline",reason="dropped_oldest",symbol="BTCUSDT"}`,
+		},
+		{
+			name:   "price update",
+			queued: func(s *Subscriber) bool { return s.Send("BTCUSDT", map[string]any{"lastPrice": 1.5}) },
+			series: `websocket_messages_dropped_total{channel="candles",reason="dropped_oldest",symbol="BTCUSDT"}`,
+		},
+		{
+			name:   "closed kline",
+			queued: func(s *Subscriber) bool { return s.SendKlineClosed(hourly, map[string]any{"closed": true}) },
+			series: `websocket_messages_dropped_total{channel="candles",reason="queue_full",symbol="ETHUSDT"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics.New()
+			delivery := testDelivery()
+			delivery.SendQueueSize = 1
+			sub := NewSubscriber(nil, delivery, m, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			if !tt.queued(sub) {
+				t.Fatal("first message not queued")
+			}
+			sub.Send("ETHUSDT", map[string]any{"lastPrice": 2.5})
+
+			// Kline keys like BTCUSDT@1h0m0s never leak into the symbol label
+			if got := metricValue(t, m, tt.series); got != "1" {
+				t.Errorf("%s = %q, want 1", tt.series, got)
+			}
+		})
+	}
+}
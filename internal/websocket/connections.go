@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sort"
+)
+
+// connectionIDBytes is the number of random bytes in a connection ID.
+const connectionIDBytes = 8
+
+// ConnectionInfo describes an open WebSocket connection for operators.
+type ConnectionInfo struct {
+	ID           string   `json:"id"`         // Same as the conn attribute of the connection's log lines.
+	RemoteAddr   string   `json:"remoteAddr"` // Client IP, the port is left out.
+	Symbols      []string `json:"symbols"`
+	ConnectedAt  int64    `json:"connectedAt"`  // Milliseconds since the epoch.
+	MessagesSent int64    `json:"messagesSent"` // Frames written, a batch frame counts once.
+	LastActivity int64    `json:"lastActivity"` // Milliseconds since the epoch of the last client message or pong.
+}
+
+// ConnectionInfos returns the open connections, oldest first.
+func (m *Manager) ConnectionInfos() []ConnectionInfo {
+	m.mu.Lock()
+	subs := m.snapshot()
+	m.mu.Unlock()
+
+	infos := make([]ConnectionInfo, 0, len(subs))
+	for _, sub := range subs {
+		infos = append(infos, sub.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ConnectedAt < infos[j].ConnectedAt })
+	return infos
+}
+
+// newConnectionID returns a random ID for a new connection.
+func newConnectionID() string {
+	id := make([]byte, connectionIDBytes)
+	_, _ = rand.Read(id) // Never fails, see crypto/rand.Read
+	return hex.EncodeToString(id)
+}
+
+// info snapshots the connection details of the subscriber.
+func (s *Subscriber) info() ConnectionInfo {
+	addr := s.conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	symbols := s.Symbols()
+	sort.Strings(symbols)
+
+	return ConnectionInfo{
+		ID:           s.id,
+		RemoteAddr:   addr,
+		Symbols:      symbols,
+		ConnectedAt:  s.connectedAt.UnixMilli(),
+		MessagesSent: s.sent.Load(),
+		LastActivity: s.LastActivity().UnixMilli(),
+	}
+}
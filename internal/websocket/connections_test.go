@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a logger.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestNewConnectionID(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{16}$`)
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := newConnectionID()
+		if !format.MatchString(id) {
+			t.Fatalf("ID %q is not %d hex encoded bytes", id, connectionIDBytes)
+		}
+		if seen[id] {
+			t.Fatalf("ID %q returned twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestConnectionInfos(t *testing.T) {
+	m := newTestManager(testDelivery())
+	first, _ := connect(t, m)
+	time.Sleep(2 * time.Millisecond) // Connection times are compared in milliseconds
+	second, _ := connect(t, m)
+	second.AddSymbol("ETHUSDT")
+	second.AddSymbol("BTCUSDT")
+
+	infos := m.ConnectionInfos()
+	if len(infos) != 2 {
+		t.Fatalf("got %d connections, want 2", len(infos))
+	}
+	if infos[0].ID != first.ID() || infos[1].ID != second.ID() || first.ID() == second.ID() {
+		t.Errorf("IDs %s and %s, want %s then %s", infos[0].ID, infos[1].ID, first.ID(), second.ID())
+	}
+	if got := infos[1].Symbols; !slices.Equal(got, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("symbols = %v, want them sorted", got)
+	}
+	if addr := infos[0].RemoteAddr; addr != "127.0.0.1" {
+		t.Errorf("remote address = %q, want the client IP without its port", addr)
+	}
+}
+
+func TestConnectionLogsTaggedWithID(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	m := NewWebSocketManager(logger, testDelivery(), naming.StyleCamel, naming.NumbersDefault, metrics.New())
+	sub, client := connect(t, m)
+
+	if err := client.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye")); err != nil {
+		t.Fatalf("sending close: %v", err)
+	}
+	waitClosed(t, sub, time.Second)
+
+	line := ""
+	for _, l := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(l, "WebSocket connection closed") {
+			line = l
+		}
+	}
+	if !strings.Contains(line, "conn="+sub.ID()) {
+		t.Fatalf("close log line %q is not tagged with conn=%s", line, sub.ID())
+	}
+}
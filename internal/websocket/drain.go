@@ -0,0 +1,171 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// messageTypeDraining announces that the server is going away and clients should reconnect.
+const messageTypeDraining = "draining"
+
+// drainCloseText is the reason given in the close frame of connections still open after a drain.
+const drainCloseText = "server draining"
+
+// drainingMessage is the event sent to every client when draining starts.
+type drainingMessage struct {
+	Type    string `json:"type"`
+	GraceMs int64  `json:"graceMs"` // Time left before the server closes the connection.
+}
+
+// track registers an open connection.
+func (m *Manager) track(sub *Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers[sub] = struct{}{}
+}
+
+// forget unregisters a closed connection and signals a pending drain once none are left.
+func (m *Manager) forget(sub *Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subscribers, sub)
+	if len(m.subscribers) == 0 && m.drained != nil {
+		close(m.drained)
+		m.drained = nil
+	}
+}
+
+// Connections returns the number of open WebSocket connections.
+func (m *Manager) Connections() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.subscribers)
+}
+
+// Drain announces the shutdown to every client and waits for them to disconnect, up to
+// grace or until ctx ends, whichever comes first. Connections still open afterwards are
+// closed with a going-away close frame. It returns the number of connections it closed.
+func (m *Manager) Drain(ctx context.Context, grace time.Duration) int {
+	ctx, cancel := context.WithTimeout(ctx, grace)
+	defer cancel()
+
+	m.draining.Store(true)
+	m.mu.Lock()
+	subs := m.snapshot()
+	drained := make(chan struct{})
+	if len(subs) == 0 {
+		close(drained)
+	} else {
+		m.drained = drained
+	}
+	m.mu.Unlock()
+
+	notice := grace
+	if deadline, ok := ctx.Deadline(); ok {
+		notice = time.Until(deadline)
+	}
+	m.announceDrain(subs, notice)
+
+	select {
+	case <-drained:
+		return 0
+	case <-ctx.Done():
+	}
+
+	m.mu.Lock()
+	m.drained = nil
+	subs = m.snapshot()
+	m.mu.Unlock()
+
+	// Closed side by side, so clients that stopped reading don't add up their close timeouts
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.closeDrained(sub)
+		}()
+	}
+	wg.Wait()
+	m.logger.Info("Closed remaining WebSocket connections", "connections", len(subs))
+	return len(subs)
+}
+
+// BeginDrain switches the manager to drain mode without blocking: new connections are refused,
+// every client gets the draining event and open connections are closed one at a time, spread
+// evenly over window. Calling it again while draining changes nothing. It returns the number
+// of connections still open.
+func (m *Manager) BeginDrain(window time.Duration) int {
+	if !m.draining.CompareAndSwap(false, true) {
+		return m.Connections()
+	}
+
+	m.mu.Lock()
+	subs := m.snapshot()
+	m.mu.Unlock()
+
+	m.announceDrain(subs, window)
+	go m.closeGradually(subs, window)
+	return len(subs)
+}
+
+// Draining reports whether the manager refuses new connections.
+func (m *Manager) Draining() bool {
+	return m.draining.Load()
+}
+
+// closeGradually closes the given connections one by one over window, so clients reconnect
+// elsewhere in a trickle rather than all at once.
+func (m *Manager) closeGradually(subs []*Subscriber, window time.Duration) {
+	if len(subs) == 0 {
+		return
+	}
+
+	step := window / time.Duration(len(subs))
+	for _, sub := range subs {
+		time.Sleep(step)
+		select {
+		case <-sub.done:
+			continue // The client already left
+		default:
+		}
+		// A client that stopped reading must not hold up the schedule of the others
+		go m.closeDrained(sub)
+	}
+	m.logger.Info("Drain finished, all connections closed", "connections", len(subs))
+}
+
+// announceDrain sends the draining event to every client. It goes out directly, a full
+// send queue must not swallow it, and in the background: a client that stopped reading
+// holds its write lock until the write deadline, and the drain must not wait for that.
+func (m *Manager) announceDrain(subs []*Subscriber, grace time.Duration) {
+	notice := drainingMessage{Type: messageTypeDraining, GraceMs: grace.Milliseconds()}
+	for _, sub := range subs {
+		go func() {
+			if err := sub.WriteJSON(notice); err != nil {
+				sub.logger.Debug("Error sending draining event", "error", err)
+			}
+		}()
+	}
+	m.logger.Info("Draining WebSocket connections", "connections", len(subs), "grace", grace)
+}
+
+// closeDrained closes a connection with a going-away close frame carrying a reconnect hint.
+func (m *Manager) closeDrained(sub *Subscriber) {
+	if err := sub.CloseWithCode(websocket.CloseGoingAway, m.drainCloseReason()); err != nil {
+		m.logger.Debug("Error closing drained connection", "error", err)
+	}
+}
+
+// snapshot returns the open connections, the caller holds m.mu.
+func (m *Manager) snapshot() []*Subscriber {
+	subs := make([]*Subscriber, 0, len(m.subscribers))
+	for sub := range m.subscribers {
+		subs = append(subs, sub)
+	}
+	return subs
+}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDrainClientLeaves(t *testing.T) {
+	m := newTestManager(testDelivery())
+	_, client := connect(t, m)
+
+	// The client reconnects elsewhere as soon as it hears about the drain
+	go func() {
+		var msg drainingMessage
+		if err := client.ReadJSON(&msg); err == nil && msg.Type == messageTypeDraining && msg.GraceMs > 0 {
+			client.Close()
+		}
+	}()
+
+	start := time.Now()
+	if closed := m.Drain(context.Background(), 5*time.Second); closed != 0 {
+		t.Errorf("drain closed %d connections, want 0", closed)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("drain took %s after the client left", elapsed)
+	}
+	if !m.Draining() {
+		t.Error("manager is not draining")
+	}
+}
+
+func TestDrainClosesRemainingConnections(t *testing.T) {
+	delivery := testDelivery()
+	delivery.RetryAfterBase = 3 * time.Second
+	m := newTestManager(delivery)
+	_, client := connect(t, m)
+
+	// The client reads everything but never leaves on its own
+	closeErr := make(chan *websocket.CloseError, 1)
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				var ce *websocket.CloseError
+				errors.As(err, &ce)
+				closeErr <- ce
+				return
+			}
+		}
+	}()
+
+	if closed := m.Drain(context.Background(), 100*time.Millisecond); closed != 1 {
+		t.Errorf("drain closed %d connections, want 1", closed)
+	}
+
+	select {
+	case ce := <-closeErr:
+		if ce == nil || ce.Code != websocket.CloseGoingAway {
+			t.Fatalf("got close %v, want going away", ce)
+		}
+		var reason closeReason
+		if err := json.Unmarshal([]byte(ce.Text), &reason); err != nil {
+			t.Fatalf("close reason %q: %v", ce.Text, err)
+		}
+		if reason.Reason != drainCloseText || reason.RetryAfter != 3 {
+			t.Errorf("close reason %+v, want %q after 3s", reason, drainCloseText)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client got no close frame")
+	}
+}
+
+func TestDrainDoesNotWaitForStuckWriter(t *testing.T) {
+	m := newTestManager(testDelivery())
+	sub, _ := connect(t, m)
+
+	// The client never reads, so the write pump ends up blocked inside a write
+	payload := strings.Repeat("x", 256<<10)
+	for range 50 {
+		sub.Send("BTCUSDT", payload)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	start := time.Now()
+	m.Drain(context.Background(), 100*time.Millisecond)
+	// Grace, then the close frame gets closeWriteWait before the connection is dropped
+	if elapsed, limit := time.Since(start), 100*time.Millisecond+closeWriteWait+time.Second; elapsed > limit {
+		t.Errorf("drain took %s, want under %s", elapsed, limit)
+	}
+	waitClosed(t, sub, time.Second)
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		base, jitter time.Duration
+		min, max     time.Duration
+	}{
+		{"no jitter", 5 * time.Second, 0, 5 * time.Second, 5 * time.Second},
+		{"rounded up", 1500 * time.Millisecond, 0, 2 * time.Second, 2 * time.Second},
+		{"jitter", 2 * time.Second, 3 * time.Second, 2 * time.Second, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := testDelivery()
+			delivery.RetryAfterBase, delivery.RetryAfterJitter = tt.base, tt.jitter
+			m := newTestManager(delivery)
+
+			seen := make(map[time.Duration]bool)
+			for range 100 {
+				got := m.RetryAfter()
+				if got < tt.min || got > tt.max || got%time.Second != 0 {
+					t.Fatalf("RetryAfter = %s, want whole seconds within [%s, %s]", got, tt.min, tt.max)
+				}
+				seen[got] = true
+			}
+			// Four possible values over 100 draws, a single one means the jitter isn't applied
+			if tt.jitter > 0 && len(seen) < 2 {
+				t.Errorf("RetryAfter always %v, want it spread by the jitter", seen)
+			}
+		})
+	}
+}
+
+func TestBeginDrainAnnouncesAndCloses(t *testing.T) {
+	delivery := testDelivery()
+	delivery.RetryAfterBase = time.Second
+	delivery.RetryAfterJitter = 2 * time.Second
+	m := newTestManager(delivery)
+	_, client := connect(t, m)
+
+	const window = 200 * time.Millisecond
+	if open := m.BeginDrain(window); open != 1 {
+		t.Fatalf("BeginDrain reported %d open connections, want 1", open)
+	}
+
+	if err := client.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("setting read deadline: %v", err)
+	}
+	var notice drainingMessage
+	if err := client.ReadJSON(&notice); err != nil {
+		t.Fatalf("reading draining event: %v", err)
+	}
+	if notice.Type != messageTypeDraining || notice.GraceMs != window.Milliseconds() {
+		t.Errorf("got %+v, want a draining event with %dms grace", notice, window.Milliseconds())
+	}
+
+	_, _, err := client.ReadMessage()
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.CloseGoingAway {
+		t.Fatalf("got %v, want a going away close", err)
+	}
+	var reason closeReason
+	if err := json.Unmarshal([]byte(ce.Text), &reason); err != nil {
+		t.Fatalf("close reason %q: %v", ce.Text, err)
+	}
+	if reason.Reason != drainCloseText || reason.RetryAfter < 1 || reason.RetryAfter > 3 {
+		t.Errorf("close reason %+v, want %q after 1 to 3s", reason, drainCloseText)
+	}
+}
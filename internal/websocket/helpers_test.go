@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// testDelivery returns delivery settings with small limits, tests change what they exercise.
+func testDelivery() config.WebSocketConfig {
+	return config.WebSocketConfig{
+		Backpressure:   config.BackpressureDropOldest,
+		BlockTimeout:   50 * time.Millisecond,
+		BacklogTimeout: time.Second,
+		DrainTimeout:   time.Second,
+		SendQueueSize:  8,
+		MaxMessageSize: 4096,
+	}
+}
+
+// newTestManager creates a manager that logs nowhere.
+func newTestManager(delivery config.WebSocketConfig) *Manager {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewWebSocketManager(logger, delivery, naming.StyleCamel, naming.NumbersDefault, metrics.New())
+}
+
+// connect opens a WebSocket connection to a test server of m and returns the server side
+// subscriber and the client end. The server reads like the real handler, closing the
+// subscriber when the connection fails.
+func connect(t *testing.T, m *Manager) (*Subscriber, *websocket.Conn) {
+	t.Helper()
+
+	subs := make(chan *Subscriber, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := m.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		subs <- sub
+		for {
+			if _, _, err := sub.Conn().ReadMessage(); err != nil {
+				sub.Close()
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	select {
+	case sub := <-subs:
+		return sub, client
+	case <-time.After(time.Second):
+		t.Fatal("server did not accept the connection")
+		return nil, nil
+	}
+}
+
+// waitClosed fails the test unless the subscriber closes within d.
+func waitClosed(t *testing.T, sub *Subscriber, d time.Duration) {
+	t.Helper()
+
+	select {
+	case <-sub.done:
+	case <-time.After(d):
+		t.Fatalf("subscriber still open after %s", d)
+	}
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import "time"
+
+// Kline identifies a subscription to the bars of one pair in one candle interval.
+type Kline struct {
+	Symbol   string
+	Interval time.Duration
+}
+
+// key is the queue key of the kline's updates, so a queued bar is replaced by a newer one of
+// the same kline but never by another interval's.
+func (k Kline) key() string {
+	return k.Symbol + "@" + k.Interval.String()
+}
+
+// AddKline records a kline subscription. It reports false if the client was already subscribed.
+func (s *Subscriber) AddKline(k Kline) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.klines[k] {
+		return false
+	}
+	s.klines[k] = true
+	return true
+}
+
+// RemoveKline forgets a kline subscription. It reports false if the client was not subscribed.
+func (s *Subscriber) RemoveKline(k Kline) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.klines[k] {
+		return false
+	}
+	delete(s.klines, k)
+	return true
+}
+
+// Klines returns the kline subscriptions of the client.
+func (s *Subscriber) Klines() []Kline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	klines := make([]Kline, 0, len(s.klines))
+	for k := range s.klines {
+		klines = append(klines, k)
+	}
+	return klines
+}
+
+// SendKline queues an update of the bar in progress, subject to batching and rate limits
+// like price updates.
+func (s *Subscriber) SendKline(k Kline, update any) bool {
+	return s.enqueue(outgoing{symbol: k.Symbol, interval: k.Interval, channel: channelKline, payload: update})
+}
+
+// SendKlineClosed queues the final frame of a closed bar. Like a control frame it is never
+// coalesced or rate limited, so the client is sure to see every bar close.
+func (s *Subscriber) SendKlineClosed(k Kline, frame any) bool {
+	return s.enqueue(outgoing{symbol: k.Symbol, interval: k.Interval, channel: channelKline, payload: frame, control: true})
+}
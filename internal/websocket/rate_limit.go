@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"time"
+)
+
+// dropReasonRateLimited labels updates superseded by a newer one while the connection
+// was over its message rate.
+const dropReasonRateLimited = "rate_limited"
+
+// rateLimiter is a token bucket allowing rate frames per second. It holds a single token, so
+// frames are at least 1/rate apart and no second ever sees more than rate of them. A zero
+// rate allows everything.
+type rateLimiter struct {
+	rate   int
+	tokens float64
+	last   time.Time
+}
+
+// setRate changes the allowed rate, starting with a full bucket when it actually changes.
+func (l *rateLimiter) setRate(rate int) {
+	if rate == l.rate {
+		return
+	}
+	l.rate = rate
+	l.tokens = 1
+	l.last = time.Now()
+}
+
+// refill adds the tokens earned since the last call.
+func (l *rateLimiter) refill(now time.Time) {
+	l.tokens = min(1, l.tokens+now.Sub(l.last).Seconds()*float64(l.rate))
+	l.last = now
+}
+
+// allow takes a token if one is available.
+func (l *rateLimiter) allow(now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	l.refill(now)
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// wait returns the time until the next token is available.
+func (l *rateLimiter) wait(now time.Time) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.refill(now)
+	return time.Duration((1 - l.tokens) / float64(l.rate) * float64(time.Second))
+}
+
+// throttledUpdates holds updates waiting for the rate limit, one per key. A newer update
+// for a key replaces the waiting one in place, so keys keep their order.
+type throttledUpdates struct {
+	order   []string
+	updates map[string]outgoing
+}
+
+// put stores an update, reporting whether it replaced one with the same key.
+func (t *throttledUpdates) put(msg outgoing) bool {
+	if t.updates == nil {
+		t.updates = make(map[string]outgoing)
+	}
+	key := msg.key()
+	_, replaced := t.updates[key]
+	if !replaced {
+		t.order = append(t.order, key)
+	}
+	t.updates[key] = msg
+	return replaced
+}
+
+// pop removes and returns the longest waiting update.
+func (t *throttledUpdates) pop() outgoing {
+	key := t.order[0]
+	t.order = t.order[1:]
+	msg := t.updates[key]
+	delete(t.updates, key)
+	return msg
+}
+
+// len returns the number of waiting updates.
+func (t *throttledUpdates) len() int {
+	return len(t.order)
+}
+
+// SetMaxRate limits the update frames written to this client per second, 0 for no limit.
+// Control frames are never limited.
+func (s *Subscriber) SetMaxRate(rate int) {
+	s.maxRate.Store(int64(rate))
+}
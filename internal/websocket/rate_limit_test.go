@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// framesWithin sends updates for distinct symbols, so none replaces another, and counts the
+// frames the client receives within d.
+func framesWithin(t *testing.T, sub *Subscriber, client *websocket.Conn, updates int, d time.Duration) int {
+	t.Helper()
+
+	deadline := time.Now().Add(d)
+	for i := range updates {
+		if !sub.Send(string(rune('A'+i))+"USDT", map[string]any{"lastPrice": float64(i)}) {
+			t.Fatalf("update %d not queued", i)
+		}
+	}
+	if err := client.SetReadDeadline(deadline); err != nil {
+		t.Fatalf("setting read deadline: %v", err)
+	}
+	frames := 0
+	for {
+		if _, _, err := client.ReadMessage(); err != nil {
+			return frames
+		}
+		frames++
+	}
+}
+
+func TestMaxRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverRate int
+		clientRate int // Rate given on a subscribe message, -1 when the client sets none.
+		wantMax    int
+		wantMin    int
+	}{
+		{"server cap", 2, -1, 2, 1},
+		{"client cap without server cap", 0, 3, 3, 1},
+		{"client cap below the server's", 5, 1, 1, 1},
+		{"client lifts the cap", 2, 0, 6, 6},
+		{"no cap", 0, -1, 6, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := testDelivery()
+			delivery.MaxMessageRate = tt.serverRate
+			sub, client := connect(t, newTestManager(delivery))
+			if tt.clientRate >= 0 {
+				sub.SetMaxRate(tt.clientRate)
+			}
+
+			if got := framesWithin(t, sub, client, 6, time.Second); got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("got %d frames within a second, want %d to %d", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRateLimiterSpacesFrames(t *testing.T) {
+	var l rateLimiter
+	l.setRate(4)
+	start := l.last
+
+	allowed := 0
+	for at := time.Duration(0); at < time.Second; at += 10 * time.Millisecond {
+		if l.allow(start.Add(at)) {
+			allowed++
+		}
+	}
+	if allowed != 4 {
+		t.Errorf("allowed %d frames within a second, want 4", allowed)
+	}
+	if wait := l.wait(start.Add(time.Second - 10*time.Millisecond)); wait <= 0 || wait > 250*time.Millisecond {
+		t.Errorf("wait = %s, want at most a quarter second", wait)
+	}
+}
@@ -0,0 +1,46 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"time"
+)
+
+// closeReason is the JSON payload of the close frame sent to drained clients.
+type closeReason struct {
+	Reason     string `json:"reason"`
+	RetryAfter int    `json:"retryAfter"` // Seconds to wait before reconnecting, as in Retry-After.
+}
+
+// RetryAfter returns how long a client should wait before reconnecting: the configured base
+// plus a random share of the jitter, so clients sent away together don't return together.
+// It is rounded up to whole seconds for the Retry-After header.
+func (m *Manager) RetryAfter() time.Duration {
+	m.mu.Lock()
+	base, jitter := m.delivery.RetryAfterBase, m.delivery.RetryAfterJitter
+	m.mu.Unlock()
+
+	delay := base
+	if jitter > 0 {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(jitter)+1))
+		if err != nil {
+			m.logger.Error("Error generating retry jitter", "error", err)
+		} else {
+			delay += time.Duration(n.Int64())
+		}
+	}
+	return (delay + time.Second - 1).Truncate(time.Second)
+}
+
+// drainCloseReason builds the close frame text of a drained connection.
+func (m *Manager) drainCloseReason() string {
+	reason, err := json.Marshal(closeReason{
+		Reason:     drainCloseText,
+		RetryAfter: int(m.RetryAfter() / time.Second),
+	})
+	if err != nil {
+		return drainCloseText
+	}
+	return string(reason)
+}
@@ -0,0 +1,18 @@
+package websocket
+
+// messageTypeSnapshot carries the whole candle history of a pair after it was replaced.
+const messageTypeSnapshot = "snapshot"
+
+// snapshotMessage is the frame sent to subscribers of a pair whose history was replaced.
+type snapshotMessage struct {
+	Type    string `json:"type"`
+	Symbol  string `json:"symbol"`
+	Candles any    `json:"candles"` // Oldest first.
+}
+
+// SendSnapshot queues the candle history of a pair. Like a control frame it is never
+// coalesced or rate limited, and it is counted on the candles channel.
+func (s *Subscriber) SendSnapshot(symbol string, candles any) bool {
+	frame := snapshotMessage{Type: messageTypeSnapshot, Symbol: symbol, Candles: candles}
+	return s.enqueue(outgoing{symbol: symbol, channel: channelCandles, payload: frame, control: true})
+}
@@ -0,0 +1,357 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// Time limits of writes to the client. A client that stops reading fails the write instead
+// of holding up the goroutine writing to it.
+const (
+	pingWriteWait  = 5 * time.Second  // Time allowed to write a ping.
+	writeWait      = 10 * time.Second // Time allowed to write one frame.
+	closeWriteWait = time.Second      // Time allowed to send the close frame, the connection is closed either way.
+)
+
+// Subscriber holds the per-connection state of a WebSocket client.
+type Subscriber struct {
+	conn    *websocket.Conn
+	id      string     // Correlates the log lines of one connection, set by the Manager.
+	writeMu sync.Mutex // Serializes writes, gorilla allows only one concurrent writer.
+	logger  *slog.Logger
+	metrics *metrics.Metrics
+
+	send      chan outgoing // Outgoing updates drained by the write pump.
+	done      chan struct{} // Closed when the subscriber shuts down.
+	closeOnce sync.Once
+	onClose   func()       // Called once when the subscriber closes, set by the Manager.
+	batching  atomic.Bool  // Whether updates are coalesced into batch frames.
+	formatted atomic.Bool  // Whether updates carry display strings next to the numbers.
+	maxRate   atomic.Int64 // Update frames per second the write pump may send, 0 for no limit.
+	naming    string       // JSON key style of frames.
+	numbers   string       // JSON number format of frames.
+
+	delivery  config.WebSocketConfig // Backpressure timeouts and queue size, see policy for the policy.
+	policy    atomic.Value           // Backpressure policy, a string that may be overridden per connection.
+	queueMu   sync.Mutex             // Serializes sends to the queue while a dropOldest policy may reorder it.
+	evicting  []outgoing             // Reused while the queue is rebuilt around an eviction, guarded by queueMu.
+	fullSince atomic.Int64           // Unix nanoseconds since the queue has been full, 0 while it has room.
+	fullRuns  atomic.Int64           // Broadcasts in a row that found the queue full.
+	closing   atomic.Bool            // Set once a slow consumer disconnect is under way.
+
+	mu         sync.RWMutex
+	fields     map[string]bool // Broadcast fields the client asked for, nil means all of them.
+	timeFormat string          // Format of candle times, empty means milliseconds.
+	symbols    map[string]bool // Symbols the client is subscribed to.
+	klines     map[Kline]bool  // Bars of a pair in one interval the client is subscribed to.
+
+	connectedAt  time.Time
+	sent         atomic.Int64 // Frames written to the client.
+	lastActivity atomic.Int64 // Unix nanoseconds of the last message or pong from the client.
+}
+
+// NewSubscriber wraps an upgraded connection. The write pump is started by the Manager.
+func NewSubscriber(
+	conn *websocket.Conn,
+	delivery config.WebSocketConfig,
+	m *metrics.Metrics,
+	logger *slog.Logger,
+) *Subscriber {
+	sub := &Subscriber{
+		conn:        conn,
+		logger:      logger,
+		metrics:     m,
+		send:        make(chan outgoing, delivery.SendQueueSize),
+		done:        make(chan struct{}),
+		delivery:    delivery,
+		symbols:     make(map[string]bool),
+		klines:      make(map[Kline]bool),
+		connectedAt: time.Now(),
+	}
+	sub.maxRate.Store(int64(delivery.MaxMessageRate))
+	sub.policy.Store(delivery.Backpressure)
+	sub.Touch()
+	return sub
+}
+
+// ID returns the connection ID, included in every log line about the connection.
+func (s *Subscriber) ID() string {
+	return s.id
+}
+
+// Touch records client activity.
+func (s *Subscriber) Touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns the time the client was last heard from.
+func (s *Subscriber) LastActivity() time.Time {
+	return time.Unix(0, s.lastActivity.Load())
+}
+
+// Ping sends a ping frame, the client's pong counts as activity.
+func (s *Subscriber) Ping() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingWriteWait))
+}
+
+// Conn returns the underlying WebSocket connection.
+func (s *Subscriber) Conn() *websocket.Conn {
+	return s.conn
+}
+
+// AddSymbol records a subscription to symbol. It reports false if the client was already subscribed.
+func (s *Subscriber) AddSymbol(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.symbols[symbol] {
+		return false
+	}
+	s.symbols[symbol] = true
+	return true
+}
+
+// RemoveSymbol forgets a subscription to symbol. It reports false if the client was not subscribed.
+func (s *Subscriber) RemoveSymbol(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.symbols[symbol] {
+		return false
+	}
+	delete(s.symbols, symbol)
+	return true
+}
+
+// Symbols returns the symbols the client is subscribed to.
+func (s *Subscriber) Symbols() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	symbols := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// SetFields restricts broadcasts to the given payload fields. An empty list restores the full payload.
+func (s *Subscriber) SetFields(fields []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(fields) == 0 {
+		s.fields = nil
+		return
+	}
+
+	s.fields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		s.fields[field] = true
+	}
+}
+
+// Mask returns the part of the update this subscriber asked for.
+func (s *Subscriber) Mask(update map[string]any) map[string]any {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.fields == nil {
+		return update
+	}
+
+	masked := make(map[string]any, len(s.fields))
+	for key, value := range update {
+		if s.fields[key] {
+			masked[key] = value
+		}
+	}
+	return masked
+}
+
+// SetTimeFormat selects how candle times are rendered in updates to this client.
+func (s *Subscriber) SetTimeFormat(format string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeFormat = format
+}
+
+// TimeFormat returns the candle time format the client asked for, empty for milliseconds.
+func (s *Subscriber) TimeFormat() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.timeFormat
+}
+
+// SetBatching switches between one frame per update and coalesced batch frames.
+func (s *Subscriber) SetBatching(enabled bool) {
+	s.batching.Store(enabled)
+}
+
+// SetFormatted switches the display strings of prices and price change in updates on or off.
+func (s *Subscriber) SetFormatted(enabled bool) {
+	s.formatted.Store(enabled)
+}
+
+// Formatted reports whether updates to this client carry display strings.
+func (s *Subscriber) Formatted() bool {
+	return s.formatted.Load()
+}
+
+// SetBackpressure overrides the backpressure policy of this connection. It is safe to call
+// while updates are broadcast, later sends apply the new policy.
+func (s *Subscriber) SetBackpressure(policy string) {
+	s.policy.Store(policy)
+}
+
+// backpressure returns the policy applied when the send queue is full.
+func (s *Subscriber) backpressure() string {
+	policy, _ := s.policy.Load().(string)
+	return policy
+}
+
+// Channels of outgoing frames, used as metric labels. The set is fixed to keep the label
+// cardinality bounded.
+const (
+	channelCandles = "candles" // Price and live base candle updates.
+	channelKline   = "kline"   // Bars of one candle interval.
+	channelControl = "control" // Connection-level frames such as acks, errors and notices.
+)
+
+// outgoing is a queued message with the pair it belongs to, empty for connection-level frames.
+type outgoing struct {
+	symbol   string
+	interval time.Duration // Bar interval of kline frames.
+	channel  string
+	payload  any
+	control  bool // Written on its own, never folded into a batch.
+}
+
+// key identifies what the message updates, a newer message with the same key supersedes it.
+func (m outgoing) key() string {
+	if m.channel == channelKline {
+		return Kline{Symbol: m.symbol, Interval: m.interval}.key()
+	}
+	return m.symbol
+}
+
+// Send queues an update of symbol for the write pump. When the queue is full the connection's
+// backpressure policy decides what happens; it reports false if the update was not queued.
+func (s *Subscriber) Send(symbol string, update any) bool {
+	return s.enqueue(outgoing{symbol: symbol, channel: channelCandles, payload: update})
+}
+
+// SendControl queues a connection-level frame, such as an error reply, behind the updates
+// already queued so the client sees events in order.
+func (s *Subscriber) SendControl(frame any) bool {
+	return s.enqueue(outgoing{channel: channelControl, payload: frame, control: true})
+}
+
+// enqueue puts a message on the send queue, applying the backpressure policy when it is full.
+func (s *Subscriber) enqueue(msg outgoing) bool {
+	if s.closing.Load() {
+		s.dropped(msg, dropReasonSlowConsumer)
+		return false
+	}
+
+	policy := s.backpressure()
+	if policy != config.BackpressureBlock && policy != config.BackpressureDisconnect {
+		// An eviction takes the queue apart and puts it back, no other send may slip in meanwhile
+		s.queueMu.Lock()
+		defer s.queueMu.Unlock()
+	}
+
+	select {
+	case <-s.done:
+		return false
+	case s.send <- msg:
+		s.fullSince.Store(0)
+		s.fullRuns.Store(0)
+		return true
+	default:
+	}
+
+	s.countFullQueue()
+	switch policy {
+	case config.BackpressureBlock:
+		return s.sendBlocking(msg)
+	case config.BackpressureDisconnect:
+		s.disconnectIfBacklogged(msg)
+		return false
+	default:
+		return s.sendDroppingOldest(msg)
+	}
+}
+
+// WriteJSON sends a connection-level JSON frame to the client immediately, bypassing the queue.
+func (s *Subscriber) WriteJSON(v any) error {
+	data, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+	if err := s.writeMessage(data); err != nil {
+		return err
+	}
+	s.metrics.MessageSent(channelControl, len(data))
+	return nil
+}
+
+// encode renders a frame as JSON in the connection's key style and number format.
+func (s *Subscriber) encode(v any) ([]byte, error) {
+	frame, err := naming.Transform(v, s.naming)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return nil, err
+	}
+	if s.numbers == naming.NumbersPlain {
+		data = naming.PlainNumbers(data)
+	}
+	return data, nil
+}
+
+// writeMessage writes one encoded frame, failing if the client doesn't take it within writeWait.
+func (s *Subscriber) writeMessage(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Close stops the write pump and closes the underlying connection. It is safe to call more than once.
+func (s *Subscriber) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+		if s.onClose != nil {
+			s.onClose()
+		}
+	})
+	return err
+}
+
+// CloseWithCode tells the client why the connection ends with a close frame, then closes it.
+// The close frame doesn't wait for writeMu, gorilla allows control frames next to a writer,
+// so a writer stuck on a client that stopped reading delays it by closeWriteWait at most.
+func (s *Subscriber) CloseWithCode(code int, text string) error {
+	err := s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text),
+		time.Now().Add(closeWriteWait))
+	if err != nil {
+		s.logger.Debug("Error sending close frame", "error", err)
+	}
+	return s.Close()
+}
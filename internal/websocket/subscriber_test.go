@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"io"
+	"log/slog"
+	"maps"
+	"testing"
+
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+)
+
+// newTestSubscriber creates a subscriber without a connection, for tests of its settings.
+func newTestSubscriber() *Subscriber {
+	return NewSubscriber(nil, testDelivery(), metrics.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestSubscriberMask(t *testing.T) {
+	update := map[string]any{"symbol": "BTCUSDT", "lastPrice": 100.0, "priceChange": 1.5}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   map[string]any
+	}{
+		{"no selection", nil, update},
+		{"empty selection restores all", []string{}, update},
+		{"selected fields", []string{"symbol", "lastPrice"}, map[string]any{"symbol": "BTCUSDT", "lastPrice": 100.0}},
+		{"field missing from update", []string{"markPrice"}, map[string]any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newTestSubscriber()
+			sub.SetFields([]string{"symbol"})
+			sub.SetFields(tt.fields)
+
+			if got := sub.Mask(update); !maps.Equal(got, tt.want) {
+				t.Errorf("Mask = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControlFramesKeepQueueOrder(t *testing.T) {
+	sub, client := connect(t, newTestManager(testDelivery()))
+
+	for i := range 3 {
+		sub.Send("BTCUSDT", map[string]any{"seq": i})
+	}
+	sub.SendControl(map[string]any{"type": "error"})
+
+	for i := range 4 {
+		var frame map[string]any
+		if err := client.ReadJSON(&frame); err != nil {
+			t.Fatalf("reading frame %d: %v", i, err)
+		}
+		if i < 3 && frame["seq"] != float64(i) {
+			t.Fatalf("frame %d is %v, want update %d", i, frame, i)
+		}
+		if i == 3 && frame["type"] != "error" {
+			t.Fatalf("last frame is %v, want the error frame", frame)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package websocket
+
+import "github.com/gorilla/websocket"
+
+// messageTypeSymbolRemoved tells a client that a pair it was subscribed to no longer exists.
+const messageTypeSymbolRemoved = "symbol_removed"
+
+// symbolRemovedMessage is the frame sent to subscribers of a removed pair.
+type symbolRemovedMessage struct {
+	Type   string `json:"type"`
+	Symbol string `json:"symbol"`
+}
+
+// SymbolRemoved unsubscribes the client from a pair that was removed, its klines included.
+// A client with other subscriptions is told with a symbol_removed frame and stays connected; one left without
+// any is closed normally with the reason in the close frame. The close runs in the
+// background like a slow consumer disconnect, the caller must not wait on the client.
+func (s *Subscriber) SymbolRemoved(symbol string) {
+	s.RemoveSymbol(symbol)
+	for _, k := range s.Klines() {
+		if k.Symbol == symbol {
+			s.RemoveKline(k)
+		}
+	}
+
+	if len(s.Symbols()) > 0 || len(s.Klines()) > 0 {
+		if !s.SendControl(symbolRemovedMessage{Type: messageTypeSymbolRemoved, Symbol: symbol}) {
+			s.logger.Warn("Symbol removed frame not delivered", "symbol", symbol)
+		}
+		return
+	}
+
+	s.logger.Info("Closing WebSocket connection, its only symbol was removed", "symbol", symbol)
+	go func() {
+		if err := s.CloseWithCode(websocket.CloseNormalClosure, "symbol "+symbol+" removed"); err != nil {
+			s.logger.Debug("Error closing subscriber of removed symbol", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSymbolRemoved(t *testing.T) {
+	tests := []struct {
+		name      string
+		symbols   []string
+		klines    []Kline
+		wantClose bool
+	}{
+		{"other symbol left", []string{"BTCUSDT", "ETHUSDT"}, nil, false},
+		{"other kline left", []string{"ETHUSDT"}, []Kline{{"BTCUSDT", time.Hour}}, false},
+		{"only the removed symbol", []string{"ETHUSDT"}, nil, true},
+		{"only the removed symbol and its klines", []string{"ETHUSDT"}, []Kline{{"ETHUSDT", time.Hour}, {"ETHUSDT", time.Minute}}, true},
+		{"only a kline of the removed symbol", nil, []Kline{{"ETHUSDT", time.Hour}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, client := connect(t, newTestManager(testDelivery()))
+			for _, symbol := range tt.symbols {
+				sub.AddSymbol(symbol)
+			}
+			for _, k := range tt.klines {
+				sub.AddKline(k)
+			}
+
+			sub.SymbolRemoved("ETHUSDT")
+
+			for _, symbol := range sub.Symbols() {
+				if symbol == "ETHUSDT" {
+					t.Errorf("still subscribed to the removed symbol")
+				}
+			}
+			for _, k := range sub.Klines() {
+				if k.Symbol == "ETHUSDT" {
+					t.Errorf("still subscribed to kline %+v of the removed symbol", k)
+				}
+			}
+
+			if err := client.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			var frame symbolRemovedMessage
+			err := client.ReadJSON(&frame)
+			if tt.wantClose {
+				var closeErr *websocket.CloseError
+				if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "symbol ETHUSDT removed" {
+					t.Fatalf("got frame %+v, error %v, want a normal close naming the symbol", frame, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reading frame: %v", err)
+			}
+			if frame != (symbolRemovedMessage{Type: messageTypeSymbolRemoved, Symbol: "ETHUSDT"}) {
+				t.Errorf("got %+v, want a symbol_removed frame for ETHUSDT", frame)
+			}
+			select {
+			case <-sub.done:
+				t.Error("subscriber with other subscriptions was closed")
+			default:
+			}
+		})
+	}
+}
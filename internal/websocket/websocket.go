@@ -3,10 +3,19 @@ package websocket
 import (
 	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/sand/crypto-trading-app/backend/internal/config"
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
 )
 
+// Protocol is the WebSocket subprotocol of the feed. Clients may request it in
+// Sec-WebSocket-Protocol; connections without a subprotocol speak it as well.
+const Protocol = "crypto-feed-v1"
+
 // Buffer size constants to avoid magic numbers.
 const (
 	defaultBufferSize = 1024 // 1KB buffer size for WebSocket connections
@@ -14,34 +23,96 @@ const (
 
 type Manager struct {
 	upgrader websocket.Upgrader
+	naming   string // JSON key style of frames.
+	numbers  string // JSON number format of frames.
+	metrics  *metrics.Metrics
 	logger   *slog.Logger
+
+	mu          sync.Mutex
+	delivery    config.WebSocketConfig   // Delivery settings applied to new connections.
+	subscribers map[*Subscriber]struct{} // Open connections.
+	drained     chan struct{}            // Closed when the last connection goes away during a drain.
+	draining    atomic.Bool              // Set once new connections are refused.
 }
 
-func NewWebSocketManager(logger *slog.Logger) *Manager {
+func NewWebSocketManager(
+	logger *slog.Logger,
+	delivery config.WebSocketConfig,
+	jsonNaming, jsonNumbers string,
+	m *metrics.Metrics,
+) *Manager {
 	return &Manager{
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  defaultBufferSize,
 			WriteBufferSize: defaultBufferSize,
+			Subprotocols:    []string{Protocol},
 			CheckOrigin: func(_ *http.Request) bool {
 				return true // Allow connections from any origin
 			},
 		},
-		logger: logger,
+		delivery:    delivery,
+		naming:      jsonNaming,
+		numbers:     jsonNumbers,
+		metrics:     m,
+		logger:      logger,
+		subscribers: make(map[*Subscriber]struct{}),
 	}
 }
 
-func (m *Manager) Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+// Upgrade upgrades the HTTP connection and wraps it in a Subscriber.
+func (m *Manager) Upgrade(w http.ResponseWriter, r *http.Request) (*Subscriber, error) {
 	conn, err := m.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		m.logger.Error("Error upgrading to WebSocket", "error", err)
 		return nil, err
 	}
 
+	m.mu.Lock()
+	delivery := m.delivery
+	m.mu.Unlock()
+
+	// A larger message fails the read and closes the connection with 1009 (message too big)
+	conn.SetReadLimit(int64(delivery.MaxMessageSize))
+
+	// Every log line about the connection carries its ID
+	id := newConnectionID()
+	logger := m.logger.With("conn", id)
+
 	// Set handler for connection closure
 	conn.SetCloseHandler(func(code int, text string) error {
-		m.logger.Info("WebSocket connection closed", "code", code, "text", text)
+		logger.Info("WebSocket connection closed", "code", code, "text", text)
+		return nil
+	})
+
+	sub := NewSubscriber(conn, delivery, m.metrics, logger)
+	sub.id = id
+	sub.naming = m.naming
+	sub.numbers = m.numbers
+	sub.onClose = func() { m.forget(sub) }
+	m.track(sub)
+	go sub.writePump()
+
+	// Any pong proves the client is still alive
+	conn.SetPongHandler(func(string) error {
+		sub.Touch()
 		return nil
 	})
 
-	return conn, nil
+	return sub, nil
+}
+
+// SetSendQueueSize changes the number of updates buffered per connection. It applies to new
+// connections; the size should stay within the config bounds.
+func (m *Manager) SetSendQueueSize(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivery.SendQueueSize = n
+}
+
+// SetSlowConsumerThreshold changes how many broadcasts in a row may find a client's queue
+// full before it is disconnected, 0 disables the check. It applies to new connections.
+func (m *Manager) SetSlowConsumerThreshold(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivery.SlowConsumerThreshold = n
 }
@@ -0,0 +1,53 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMessageSizeLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		wantClose bool
+	}{
+		{"below the limit", 100, false},
+		{"at the limit", 4096, false},
+		{"above the limit", 4097, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := testDelivery()
+			delivery.MaxMessageSize = 4096
+			sub, client := connect(t, newTestManager(delivery))
+
+			if err := client.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", tt.size))); err != nil {
+				t.Fatalf("sending message: %v", err)
+			}
+
+			if err := client.SetReadDeadline(time.Now().Add(500 * time.Millisecond)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			_, _, err := client.ReadMessage()
+			if tt.wantClose {
+				if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+					t.Fatalf("got %v, want close 1009 (message too big)", err)
+				}
+				waitClosed(t, sub, time.Second)
+				return
+			}
+			if websocket.IsUnexpectedCloseError(err) || websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+				t.Fatalf("connection closed by a message within the limit: %v", err)
+			}
+			select {
+			case <-sub.done:
+				t.Error("subscriber closed by a message within the limit")
+			default:
+			}
+		})
+	}
+}
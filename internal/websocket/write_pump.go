@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// batchFlushInterval is how long updates are collected before a batch frame is sent.
+const batchFlushInterval = 200 * time.Millisecond
+
+// messageTypeBatch marks a frame carrying several coalesced updates.
+const messageTypeBatch = "batch"
+
+// batchMessage is the frame sent to clients that opted into batched delivery. The updates
+// are encoded one by one so each channel is credited with the bytes of its own updates.
+type batchMessage struct {
+	Type    string            `json:"type"`
+	Updates []json.RawMessage `json:"updates"`
+}
+
+// writePump drains the send queue and writes updates to the connection. With batching enabled,
+// updates arriving within batchFlushInterval of the first one are sent as a single frame.
+// Update frames are held to the connection's message rate: excess updates wait, and a newer
+// update for the same symbol replaces the waiting one. Control frames are always written on
+// their own and immediately.
+func (s *Subscriber) writePump() {
+	flushTimer := time.NewTimer(batchFlushInterval)
+	flushTimer.Stop()
+	defer flushTimer.Stop()
+	throttleTimer := time.NewTimer(0)
+	throttleTimer.Stop()
+	defer throttleTimer.Stop()
+
+	var limiter rateLimiter
+	var throttled throttledUpdates
+	var pending []outgoing
+	var flushC <-chan time.Time    // Nil while no batch is pending.
+	var throttleC <-chan time.Time // Nil while no update waits for the rate limit.
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.send:
+			limiter.setRate(int(s.maxRate.Load()))
+			if msg.control {
+				// Flush the batch first so the frame isn't overtaken by updates queued before it
+				if pending != nil {
+					flushTimer.Stop()
+					s.writeBatch(pending)
+					pending = nil
+					flushC = nil
+				}
+				s.write(msg)
+				continue
+			}
+			if !s.batching.Load() && pending == nil {
+				if throttled.len() == 0 && limiter.allow(time.Now()) {
+					s.write(msg)
+					continue
+				}
+				if throttled.put(msg) {
+					s.dropped(msg, dropReasonRateLimited)
+				}
+				if throttleC == nil {
+					throttleTimer.Reset(limiter.wait(time.Now()))
+					throttleC = throttleTimer.C
+				}
+				continue
+			}
+			// Updates still waiting for the rate limit go out with the batch, ahead of newer ones
+			for throttled.len() > 0 {
+				pending = append(pending, throttled.pop())
+			}
+			pending = append(pending, msg)
+			if flushC == nil {
+				flushTimer.Reset(batchFlushInterval)
+				flushC = flushTimer.C
+			}
+		case <-flushC:
+			// A batch over the rate limit keeps collecting until the next frame is allowed
+			if now := time.Now(); !limiter.allow(now) {
+				flushTimer.Reset(limiter.wait(now))
+				continue
+			}
+			s.writeBatch(pending)
+			pending = nil
+			flushC = nil
+		case <-throttleC:
+			throttleC = nil
+			now := time.Now()
+			for throttled.len() > 0 && limiter.allow(now) {
+				s.write(throttled.pop())
+			}
+			if throttled.len() > 0 {
+				throttleTimer.Reset(limiter.wait(now))
+				throttleC = throttleTimer.C
+			}
+		}
+	}
+}
+
+// write sends one queued message as a frame of its own.
+func (s *Subscriber) write(msg outgoing) {
+	data, err := s.encode(msg.payload)
+	if err == nil {
+		err = s.writeMessage(data)
+	}
+	if err != nil {
+		s.writeFailed(err)
+		return
+	}
+	s.sent.Add(1)
+	s.metrics.MessageSent(msg.channel, len(data))
+}
+
+// writeBatch sends several updates as one batch frame. Each update counts as a message of its
+// channel; the few bytes of the frame around the updates are not credited to any channel.
+func (s *Subscriber) writeBatch(pending []outgoing) {
+	updates := make([]json.RawMessage, len(pending))
+	for i, msg := range pending {
+		data, err := s.encode(msg.payload)
+		if err != nil {
+			s.writeFailed(err)
+			return
+		}
+		updates[i] = data
+	}
+	// The envelope keys read the same in every naming style and hold no numbers
+	data, err := json.Marshal(batchMessage{Type: messageTypeBatch, Updates: updates})
+	if err == nil {
+		err = s.writeMessage(data)
+	}
+	if err != nil {
+		s.writeFailed(err)
+		return
+	}
+	s.sent.Add(1)
+	for i, msg := range pending {
+		s.metrics.MessageSent(msg.channel, len(updates[i]))
+	}
+}
+
+// writeFailed closes the connection after a frame could not be sent, so the read loop cleans up.
+func (s *Subscriber) writeFailed(err error) {
+	s.Close()
+	if s.closing.Load() {
+		return // Expected, a slow consumer disconnect sent the close frame
+	}
+	s.logger.Error("Error sending update to subscriber", "error", err)
+	s.metrics.WriteFailed()
+}
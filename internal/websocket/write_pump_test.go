@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sand/crypto-trading-app/backend/internal/metrics"
+	"github.com/sand/crypto-trading-app/backend/internal/naming"
+)
+
+// metricValue returns the value of the sample line starting with series, e.g.
+// websocket_messages_sent_total{channel="kline"}, or "" when m doesn't report it.
+func metricValue(t *testing.T, m *metrics.Metrics, series string) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func TestMessageSentByChannel(t *testing.T) {
+	tests := []struct {
+		channel string
+		send    func(*Subscriber) bool
+	}{
+		{channelCandles, func(s *Subscriber) bool { return s.Send("BTCUSDT", map[string]any{"lastPrice": 1.5}) }},
+		{channelKline, func(s *Subscriber) bool {
+			return s.SendKline(Kline{Symbol: "BTCUSDT", Interval: time.Hour}, map[string]any{"close": 1.5})
+		}},
+		{channelControl, func(s *Subscriber) bool { return s.SendControl(map[string]any{"type": "notice"}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			m := metrics.New()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			sub, client := connect(t, NewWebSocketManager(logger, testDelivery(), naming.StyleCamel, naming.NumbersDefault, m))
+
+			if !tt.send(sub) {
+				t.Fatal("message not queued")
+			}
+			if err := client.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			_, data, err := client.ReadMessage()
+			if err != nil {
+				t.Fatalf("reading frame: %v", err)
+			}
+
+			// The frame is counted right after it is written, which may trail the client's read
+			series := `websocket_messages_sent_total{channel="` + tt.channel + `"}`
+			deadline := time.Now().Add(time.Second)
+			for metricValue(t, m, series) != "1" && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if got := metricValue(t, m, series); got != "1" {
+				t.Fatalf("%s = %q, want 1", series, got)
+			}
+			bytesSeries := `websocket_bytes_sent_total{channel="` + tt.channel + `"}`
+			if got, want := metricValue(t, m, bytesSeries), strconv.Itoa(len(data)); got != want {
+				t.Errorf("%s = %s, want the %s bytes of the frame", bytesSeries, got, want)
+			}
+			for _, other := range []string{channelCandles, channelKline, channelControl} {
+				if other == tt.channel {
+					continue
+				}
+				if got := metricValue(t, m, `websocket_messages_sent_total{channel="`+other+`"}`); got != "" {
+					t.Errorf("channel %s counted %s messages, want none", other, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchedDelivery(t *testing.T) {
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+	tests := []struct {
+		name       string
+		batching   bool
+		wantFrames int
+	}{
+		{"batched", true, 1},
+		{"unbatched", false, len(symbols)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, client := connect(t, newTestManager(testDelivery()))
+			sub.SetBatching(tt.batching)
+
+			// All updates are queued well within one flush interval
+			for _, symbol := range symbols {
+				if !sub.Send(symbol, map[string]any{"symbol": symbol}) {
+					t.Fatalf("%s update not queued", symbol)
+				}
+			}
+
+			// Frames of the updates arrive within the flush interval, nothing follows them
+			if err := client.SetReadDeadline(time.Now().Add(2 * batchFlushInterval)); err != nil {
+				t.Fatalf("setting read deadline: %v", err)
+			}
+			var frames []map[string]any
+			for {
+				var frame map[string]any
+				if err := client.ReadJSON(&frame); err != nil {
+					break
+				}
+				frames = append(frames, frame)
+			}
+			if len(frames) != tt.wantFrames {
+				t.Fatalf("got %d frames %v, want %d", len(frames), frames, tt.wantFrames)
+			}
+
+			var got []any
+			if tt.batching {
+				if frames[0]["type"] != messageTypeBatch {
+					t.Fatalf("got %v, want a batch frame", frames[0])
+				}
+				updates, _ := frames[0]["updates"].([]any)
+				for _, update := range updates {
+					got = append(got, update.(map[string]any)["symbol"])
+				}
+			} else {
+				for _, frame := range frames {
+					got = append(got, frame["symbol"])
+				}
+			}
+			if len(got) != len(symbols) {
+				t.Fatalf("got updates of %v, want %v in order", got, symbols)
+			}
+			for i, symbol := range symbols {
+				if got[i] != symbol {
+					t.Errorf("got updates of %v, want %v in order", got, symbols)
+					break
+				}
+			}
+		})
+	}
+}